@@ -0,0 +1,99 @@
+package tbuikit
+
+// A layout container which stacks things one after the other, either side
+// by side (an HBox) or top to bottom (a VBox), inside of an area given by a
+// CalcFunction.
+//
+// Instead of holding widgets itself, a box hands out a CalcFunction for each
+// slot added to it, which gets passed to the widget's constructor like any
+// other.  The slots get worked out again every time one is called, so they
+// follow the box's area around when the terminal gets resized.  A slot can
+// also be the area of another box or grid, to nest them:
+//
+//	page := CreateVBox(FullScreen)
+//	header := page.Add(Fixed(3))
+//	columns := CreateHBox(page.Add(Flex(1)))
+//	columns.SetGap(1)
+//	sidebar := columns.Add(Percent(25).WithMin(20))
+//	content := columns.Add(Flex(1))
+//
+// These shouldn't be created via new() - use the CreateHBox() and
+// CreateVBox() calls instead.
+type Box struct {
+	horizontal   bool
+	calcFunction CalcFunction
+	constraints  []Constraint
+	gap          int
+
+	paddingTop    int
+	paddingRight  int
+	paddingBottom int
+	paddingLeft   int
+}
+
+// Adds a slot to the end of the box, sized according to the constraint
+// along the box's direction and taking up all of the room the other way.
+//
+// The box doesn't know what screens its slots end up used on, so it can't
+// redraw them - when changing a box once its widgets are on a screen, call
+// the screen's DoResize to lay them out again.
+func (this *Box) Add(constraint Constraint) CalcFunction {
+	index := len(this.constraints)
+	this.constraints = append(this.constraints, constraint)
+	return func() (int, int, int, int) {
+		return this.getSlot(index)
+	}
+}
+
+// Sets how many cells are left empty between each slot.  Like with Add,
+// the screens using the box need a DoResize to pick it up.
+func (this *Box) SetGap(gap int) {
+	this.gap = gap
+}
+
+// Sets how many cells are left empty around the inside of the box's area.
+// Like with Add, the screens using the box need a DoResize to pick it up.
+func (this *Box) SetPadding(top, right, bottom, left int) {
+	this.paddingTop = top
+	this.paddingRight = right
+	this.paddingBottom = bottom
+	this.paddingLeft = left
+}
+
+// Checks if the box lays its slots out side by side
+func (this *Box) IsHorizontal() bool {
+	return this.horizontal
+}
+
+// Internal method which lays out the box and gets the corners of one slot.
+func (this *Box) getSlot(index int) (x1, x2, y1, y2 int) {
+	x1, x2, y1, y2 = this.calcFunction()
+	x1 += this.paddingLeft
+	x2 -= this.paddingRight
+	y1 += this.paddingTop
+	y2 -= this.paddingBottom
+
+	if this.horizontal {
+		offsets, sizes := layoutConstraints(x1, x2-x1+1, this.gap, this.constraints)
+		return offsets[index], offsets[index] + sizes[index] - 1, y1, y2
+	}
+	offsets, sizes := layoutConstraints(y1, y2-y1+1, this.gap, this.constraints)
+	return x1, x2, offsets[index], offsets[index] + sizes[index] - 1
+}
+
+// A "constructor" function to create a box laying out its slots side by
+// side, left to right, within the area calcFunction gives.
+func CreateHBox(calcFunction CalcFunction) *Box {
+	box := new(Box)
+	box.horizontal = true
+	box.calcFunction = calcFunction
+	return box
+}
+
+// A "constructor" function to create a box laying out its slots top to
+// bottom within the area calcFunction gives.
+func CreateVBox(calcFunction CalcFunction) *Box {
+	box := new(Box)
+	box.calcFunction = calcFunction
+	return box
+}
@@ -5,100 +5,101 @@ import (
 )
 
 // A widget which acts like a button.  It can be selected and then "pressed"
-// via a key binding.
+// via a key binding.  Its colors come from its theme (as ROLE_BUTTON), unless
+// it's given its own with SetStyle (which is what CreateButtonWidget does).
+//
+// These shouldn't be created via new() - use the NewButton() call instead.
 type ButtonWidget struct {
+	themeable
+	keyBindings
+
 	buttonText   string
 	textPosition ScreenPosition
 
-	defaultTextColor    termbox.Attribute
-	selectedTextColor   termbox.Attribute
-	defaultBorderColor  termbox.Attribute
-	selectedBorderColor termbox.Attribute
-	defaultBgColor      termbox.Attribute
-	selectedBgColor     termbox.Attribute
-
-	calcFunction      CalcFunction
-	rect              *Rectangle
-	isSelectable      bool
-	selected          bool
-	widgetKeyBindings map[interface{}]EventCallback
+	calcFunction  CalcFunction
+	rect          *Rectangle
+	isSelectable  bool
+	selected      bool
+	disabled      bool
+	focusCallback WidgetCallback
+	blurCallback  WidgetCallback
+	pressCallback ActionCallback
 }
 
 // Draw the button every iteration of the main loop.  Figure out where to put the button text within the button,
 // make sure the borders get drawn and then draw the text in the figured out location.
-func (this *ButtonWidget) Draw() {
-	if this.rect == nil {
-		this.CalculateSize()
-	}
+func (this *ButtonWidget) Draw(canvas *Canvas) {
+	rect := canvas.GetRect()
+	style := this.getStyle(canvas, this.getState())
 
-	this.drawBorderAndBg()
+	this.drawBorderAndBg(canvas, style.Border)
 
 	// Decide where the button text should be drawn.  Keep in mind
-	// the Termboxprintf function still prints a string left-to-right,
+	// the SurfacePrint function still prints a string left-to-right,
 	// so the X coord is the first rune of the string and the it advances
 	// to the right.
 	var x, y int
 
 	if this.textPosition == TOP_LEFT {
-		y = this.rect.Y1 + 1
-		x = this.rect.X1 + 1
+		y = rect.Y1 + 1
+		x = rect.X1 + 1
 	} else if this.textPosition == TOP_RIGHT {
-		y = this.rect.Y1 + 1
-		x = this.rect.X2 - len(this.buttonText) // - 1?
+		y = rect.Y1 + 1
+		x = rect.X2 - TextWidth(this.buttonText) // - 1?
 	} else if this.textPosition == BOTTOM_LEFT {
-		y = this.rect.Y2 - 1
-		x = this.rect.X1 + 1
+		y = rect.Y2 - 1
+		x = rect.X1 + 1
 	} else if this.textPosition == BOTTOM_RIGHT {
-		y = this.rect.Y2 - 1
-		x = this.rect.X2 - len(this.buttonText)
+		y = rect.Y2 - 1
+		x = rect.X2 - TextWidth(this.buttonText)
 	} else {
 		// default to center
-		y = this.rect.Y2 - (this.rect.Height() / 2)
-		x = this.rect.X2 - (this.rect.Width() / 2) - (len(this.buttonText) / 2)
+		y = rect.Y2 - (rect.Height() / 2)
+		x = rect.X2 - (rect.Width() / 2) - (TextWidth(this.buttonText) / 2)
 	}
 
+	// Text too long for the button gets cut off at the border
+	inside := canvas.Clip(CreateRectangle(rect.X1+1, rect.X2-1, rect.Y1+1, rect.Y2-1))
+	SurfacePrint(inside, x, y, style.Text.GetFg(), style.Text.GetBg(), this.buttonText)
 	if this.selected {
-		TermboxPrintf(x, y, this.selectedTextColor, this.selectedBgColor, this.buttonText)
-		termbox.HideCursor()
-	} else {
-		TermboxPrintf(x, y, this.defaultTextColor, this.defaultBgColor, this.buttonText)
+		canvas.HideCursor()
+	}
+}
+
+// Internal method for getting the state the button gets drawn in.
+func (this *ButtonWidget) getState() WidgetState {
+	if this.disabled {
+		return STATE_DISABLED
+	} else if this.selected {
+		return STATE_FOCUSED
 	}
+	return STATE_NORMAL
 }
 
 // This draws the border lines around the widget
 //
 // TODO: probably paint BG colors, thought this may need to be
 // in tandem with the normal Draw function as well.
-func (this *ButtonWidget) drawBorderAndBg() {
+func (this *ButtonWidget) drawBorderAndBg(canvas *Canvas, style Style) {
+	rect := canvas.GetRect()
 
-	var borderColor termbox.Attribute
-	if this.selected {
-		borderColor = this.selectedBorderColor | termbox.AttrBold
-	} else {
-		borderColor = this.defaultBorderColor
-	}
-
-	var bgColor termbox.Attribute
-	if this.selected {
-		bgColor = this.selectedBgColor
-	} else {
-		bgColor = this.defaultBgColor
-	}
+	borderColor := style.GetFg()
+	bgColor := style.GetBg()
 
 	// Draw corners
-	termbox.SetCell(this.rect.X1, this.rect.Y1, 0x250C, borderColor, bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y1, 0x2510, borderColor, bgColor)
-	termbox.SetCell(this.rect.X1, this.rect.Y2, 0x2514, borderColor, bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y2, 0x2518, borderColor, bgColor)
-
-	for i := this.rect.X1 + 1; i < this.rect.X2; i++ {
-		termbox.SetCell(i, this.rect.Y1, 0x2500, borderColor, bgColor)
-		termbox.SetCell(i, this.rect.Y2, 0x2500, borderColor, bgColor)
+	canvas.SetCell(rect.X1, rect.Y1, 0x250C, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y1, 0x2510, borderColor, bgColor)
+	canvas.SetCell(rect.X1, rect.Y2, 0x2514, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y2, 0x2518, borderColor, bgColor)
+
+	for i := rect.X1 + 1; i < rect.X2; i++ {
+		canvas.SetCell(i, rect.Y1, 0x2500, borderColor, bgColor)
+		canvas.SetCell(i, rect.Y2, 0x2500, borderColor, bgColor)
 	}
 
-	for i := this.rect.Y1 + 1; i < this.rect.Y2; i++ {
-		termbox.SetCell(this.rect.X1, i, 0x2502, borderColor, bgColor)
-		termbox.SetCell(this.rect.X2, i, 0x2502, borderColor, bgColor)
+	for i := rect.Y1 + 1; i < rect.Y2; i++ {
+		canvas.SetCell(rect.X1, i, 0x2502, borderColor, bgColor)
+		canvas.SetCell(rect.X2, i, 0x2502, borderColor, bgColor)
 	}
 }
 
@@ -109,9 +110,52 @@ func (this *ButtonWidget) CalculateSize() {
 	this.rect = rect
 }
 
-// Check if this widget should be flaggable as selected.
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *ButtonWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *ButtonWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
+// Check if this widget should be flaggable as selected.  Disabled buttons
+// never are.
 func (this *ButtonWidget) IsSelectable() bool {
-	return this.isSelectable
+	return this.isSelectable && !this.disabled
+}
+
+// Enables or disables the button.  A disabled button gets drawn in its
+// theme's STATE_DISABLED style, can't be selected (so it gets unselected
+// if it is) and can't be pressed.
+func (this *ButtonWidget) SetEnabled(enabled bool) {
+	this.disabled = !enabled
+	if this.disabled {
+		this.Unselect()
+	}
+	this.invalidate()
+}
+
+// Check if the button is enabled.
+func (this *ButtonWidget) IsEnabled() bool {
+	return !this.disabled
+}
+
+// Sets whether or not the widget can be selected.  Making it unselectable
+// unselects it if it is.
+func (this *ButtonWidget) SetSelectable(selectable bool) {
+	this.isSelectable = selectable
+	if !selectable {
+		this.Unselect()
+	}
 }
 
 // Check if this widget is flagged as selected.  Accessor
@@ -123,30 +167,83 @@ func (this *ButtonWidget) IsSelected() bool {
 // Selects this widget - it'd probably make sense
 // to return an error if this widget isn't selectable
 func (this *ButtonWidget) Select() {
-	if this.isSelectable {
+	if this.IsSelectable() && !this.selected {
 		this.selected = true
+		if this.focusCallback != nil {
+			this.focusCallback(this)
+		}
+		this.invalidate()
 	}
 }
 
 // Unset selection status
 func (this *ButtonWidget) Unselect() {
-	this.selected = false
+	if this.selected {
+		this.selected = false
+		if this.blurCallback != nil {
+			this.blurCallback(this)
+		}
+		this.invalidate()
+	}
+}
+
+// Sets the callback run whenever this widget gets selected.
+func (this *ButtonWidget) SetFocusCallback(callback WidgetCallback) {
+	this.focusCallback = callback
+}
+
+// Sets the callback run whenever this widget gets unselected.
+func (this *ButtonWidget) SetBlurCallback(callback WidgetCallback) {
+	this.blurCallback = callback
 }
 
 // Take widget level printable-key (rune) handler function
-func (this *ButtonWidget) AddCharKeyCallback(char rune, callback EventCallback) {
-	this.widgetKeyBindings[char] = callback
+func (this *ButtonWidget) AddCharKeyCallback(char rune, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Ch: char}, AdaptEventCallback(callback))
 }
 
 // Take widget level meta-key (termbox.Key) handler function
-func (this *ButtonWidget) AddSpecialKeyCallback(key termbox.Key, callback EventCallback) {
-	this.widgetKeyBindings[key] = callback
+func (this *ButtonWidget) AddSpecialKeyCallback(key termbox.Key, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Key: key}, AdaptEventCallback(callback))
+}
+
+// If this widget is selected, handle key inputs based on mapped keys.
+// Clicking the button with the left mouse button presses it.  A disabled
+// button ignores everything.
+func (this *ButtonWidget) HandleEvents(event Event) bool {
+	if this.disabled {
+		return false
+	}
+	if event.Type == EVENT_MOUSE {
+		if event.Mouse.Action == MOUSE_LEFT {
+			this.press(event)
+			return true
+		}
+		return false
+	}
+
+	return runKeyBinding(this.widgetKeyBindings, this, event, true) ||
+		runKeyBinding(this.widgetKeyBindings, this, event, false)
+}
+
+// Sets the callback to run when the button gets pressed.  The callback gets
+// the button and the event which pressed it - the mouse click, or an empty
+// event (of type EVENT_NONE) when Press is called directly (from a key
+// handler, for example).
+func (this *ButtonWidget) SetPressCallback(callback ActionCallback) {
+	this.pressCallback = callback
 }
 
-// If this widget is selected, handle key inputs based on mapped keys
-func (this *ButtonWidget) HandleEvents(event interface{}) {
-	if this.widgetKeyBindings[event] != nil {
-		this.widgetKeyBindings[event](this, event)
+// Presses the button, running its press callback if it has one.  Nothing
+// happens if the button is disabled.
+func (this *ButtonWidget) Press() {
+	this.press(Event{})
+}
+
+// Internal method for pressing the button on behalf of an event.
+func (this *ButtonWidget) press(event Event) {
+	if this.pressCallback != nil && !this.disabled {
+		this.pressCallback(this, event)
 	}
 }
 
@@ -155,28 +252,55 @@ func (this *ButtonWidget) SetText(btnText string) {
 	//TODO: Maybe do something about too-long text by
 	// looking at the width of the rect?
 	this.buttonText = btnText
+	this.invalidate()
 }
 
-// A "constructor" function to create new widgets.
-func CreateButtonWidget(buttonText string, textPos ScreenPosition, defTextCol, selTextCol, defBgCol, selBgCol, defBorCol, selBorCol termbox.Attribute,
-	calcFunction CalcFunction, selectable, selected bool) *ButtonWidget {
+// Sets where the text goes within the widget.
+func (this *ButtonWidget) SetTextPosition(position ScreenPosition) {
+	this.textPosition = position
+	this.invalidate()
+}
 
+// A "constructor" function to create new buttons.  Buttons start out
+// selectable, with their text centered, drawn by their theme and covering
+// the whole screen - the options change that:
+//
+//	NewButton("Save", WithLayout(calc), OnPress(save), Selected())
+func NewButton(buttonText string, options ...WidgetOption) *ButtonWidget {
 	widget := new(ButtonWidget)
+	widget.role = ROLE_BUTTON
 	widget.buttonText = buttonText
-	widget.textPosition = textPos
+	widget.textPosition = CENTER
+	widget.calcFunction = FullScreen
+	widget.isSelectable = true
+	widget.keyBindings = createKeyBindings(widget)
 
-	widget.defaultTextColor = defTextCol
-	widget.selectedTextColor = selTextCol
-	widget.defaultBgColor = defBgCol
-	widget.selectedBgColor = selBgCol
-	widget.defaultBorderColor = defBorCol
-	widget.selectedBorderColor = selBorCol
-
-	widget.calcFunction = calcFunction
-	widget.selected = selected
-	widget.isSelectable = selectable
+	applyOptions(widget, options)
+	return widget
+}
 
-	widget.widgetKeyBindings = make(map[interface{}]EventCallback)
+// A "constructor" function to create new widgets, with the colors for
+// when it's selected and when it isn't.
+//
+// Deprecated: the arguments are easy to mix up, use NewButton instead.
+func CreateButtonWidget(buttonText string, textPos ScreenPosition, defTextCol, selTextCol, defBgCol, selBgCol, defBorCol, selBorCol termbox.Attribute,
+	calcFunction CalcFunction, selectable, selected bool) *ButtonWidget {
 
-	return widget
+	options := []WidgetOption{
+		WithTextPosition(textPos),
+		WithStyle(STATE_NORMAL, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(defTextCol), fromTermbox(defBgCol)),
+			Border: CreateStyle(fromTermbox(defBorCol), fromTermbox(defBgCol)),
+		}),
+		WithStyle(STATE_FOCUSED, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(selTextCol), fromTermbox(selBgCol)),
+			Border: CreateStyle(fromTermbox(selBorCol), fromTermbox(selBgCol)).Bold(),
+		}),
+		WithLayout(calcFunction),
+		Selectable(selectable),
+	}
+	if selected {
+		options = append(options, Selected())
+	}
+	return NewButton(buttonText, options...)
 }
@@ -0,0 +1,168 @@
+package tbuikit
+
+import (
+	"github.com/mattn/go-runewidth"
+)
+
+// What widgets draw on - a window onto a surface, bound to the widget's
+// rectangle.  Coordinates on a canvas are relative to its top left corner,
+// so 0,0 is the widget's top left cell and GetRect gives the whole of it,
+// and anything drawn outside of it (like a label too long for its widget)
+// gets dropped rather than spilling onto the neighbouring widgets.
+//
+// The screen makes one for each widget it draws.  Containers make ones for
+// their children with Sub.
+type Canvas struct {
+	surface Surface
+	originX int
+	originY int
+	width   int
+	height  int
+
+	// The part of the surface which can be drawn on, in the surface's
+	// coordinates
+	clip *Rectangle
+
+	// The theme of whatever the canvas belongs to (the screen, ui or a
+	// container), for widgets without one of their own
+	theme *Theme
+}
+
+// Sets a cell, if it's on the canvas.  A wide character only gets drawn if
+// both of the cells it takes up are.
+func (this *Canvas) SetCell(x, y int, ch rune, fg, bg Color) {
+	x += this.originX
+	y += this.originY
+	if this.clip.Contains(x, y) && this.clip.Contains(x+maxInt(runewidth.RuneWidth(ch), 1)-1, y) {
+		this.surface.SetCell(x, y, ch, fg, bg)
+	}
+}
+
+// Moves the cursor, hiding it instead when it would be off of the canvas.
+func (this *Canvas) SetCursor(x, y int) {
+	x += this.originX
+	y += this.originY
+	if this.clip.Contains(x, y) {
+		this.surface.SetCursor(x, y)
+	} else {
+		this.surface.HideCursor()
+	}
+}
+
+// Hides the cursor.
+func (this *Canvas) HideCursor() {
+	this.surface.HideCursor()
+}
+
+// Gets how big the rectangle the canvas is bound to is, in cells.
+func (this *Canvas) Size() (width, height int) {
+	return this.width, this.height
+}
+
+// Gets the rectangle the canvas is bound to, in the canvas' coordinates -
+// so it always starts at 0,0.
+func (this *Canvas) GetRect() *Rectangle {
+	return CreateRectangle(0, this.width-1, 0, this.height-1)
+}
+
+// Makes a canvas for part of this one, with the rectangle in this one's
+// coordinates.  Drawing on it is clipped to both canvases.
+func (this *Canvas) Sub(rect *Rectangle) *Canvas {
+	x1 := rect.X1 + this.originX
+	y1 := rect.Y1 + this.originY
+	x2 := rect.X2 + this.originX
+	y2 := rect.Y2 + this.originY
+
+	sub := createCanvas(this.surface, x1, y1, rect.X2-rect.X1+1, rect.Y2-rect.Y1+1)
+	sub.clip = CreateRectangle(maxInt(x1, this.clip.X1), minInt(x2, this.clip.X2), maxInt(y1, this.clip.Y1), minInt(y2, this.clip.Y2))
+	sub.theme = this.theme
+	return sub
+}
+
+// Makes a canvas which draws in the same coordinates as this one, but only
+// within a rectangle of it - for keeping text inside of a border, say.
+func (this *Canvas) Clip(rect *Rectangle) *Canvas {
+	clipped := this.Sub(rect)
+	clipped.originX = this.originX
+	clipped.originY = this.originY
+	return clipped
+}
+
+// Internal method for getting a copy of the canvas which is clipped the same
+// way, but takes coordinates on the surface rather than on the canvas.
+func (this *Canvas) untranslated() *Canvas {
+	canvas := *this
+	canvas.originX = 0
+	canvas.originY = 0
+	return &canvas
+}
+
+// Fills the whole canvas with a character.
+func (this *Canvas) Fill(ch rune, fg, bg Color) {
+	for y := 0; y < this.height; y++ {
+		for x := 0; x < this.width; x++ {
+			this.SetCell(x, y, ch, fg, bg)
+		}
+	}
+}
+
+// Gets the theme widgets drawn on the canvas use when they don't have one
+// of their own - the one of the screen (or ui) they're on, unless they're
+// in a container with its own.
+func (this *Canvas) GetTheme() *Theme {
+	if this.theme == nil {
+		return defaultTheme
+	}
+	return this.theme
+}
+
+// Internal method for getting a copy of the canvas which gives widgets
+// drawn on it a different theme.  nil keeps the current one.
+func (this *Canvas) withTheme(theme *Theme) *Canvas {
+	if theme == nil {
+		return this
+	}
+	canvas := *this
+	canvas.theme = theme
+	return &canvas
+}
+
+// Gets the surface the canvas draws on, with nothing translated or clipped.
+func (this *Canvas) GetSurface() Surface {
+	return this.surface
+}
+
+// A "constructor" function to create a canvas bound to a rectangle on a
+// surface, given in the surface's coordinates.
+func CreateCanvas(surface Surface, rect *Rectangle) *Canvas {
+	return createCanvas(surface, rect.X1, rect.Y1, rect.X2-rect.X1+1, rect.Y2-rect.Y1+1)
+}
+
+// Internal function which makes a canvas of some size with its top left
+// corner at x, y on the surface, clipped to just that.
+func createCanvas(surface Surface, x, y, width, height int) *Canvas {
+	canvas := new(Canvas)
+	canvas.surface = surface
+	canvas.originX = x
+	canvas.originY = y
+	canvas.width = width
+	canvas.height = height
+	canvas.clip = CreateRectangle(x, x+width-1, y, y+height-1)
+	return canvas
+}
+
+// Gets the smaller of two ints
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Gets the bigger of two ints
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
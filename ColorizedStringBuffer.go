@@ -1,17 +1,41 @@
 package tbuikit
 
+import (
+	"strings"
+	"sync"
+)
+
 // This type wraps a string slice to be used
 // to display string-wrapper objects on the screen.
 // The objects are simple tuples, containing the string and the color
 // to display it as.
 // Has a maximum size and will truncate old strings once this is reached
+//
+// It's safe to add to the buffer from any goroutine while the main loop
+// is drawing it.
 type ColorizedStringBuffer struct {
-	holder   []*ColorizedString
-	capacity int
+	screenLink
+
+	lock       sync.Mutex
+	holder     []*ColorizedString
+	capacity   int
+	wrapIndent int
+}
+
+// Internal method for telling the buffer what screen the widget showing it
+// is on, so adding to it only redraws that screen's ui.
+func (this *ColorizedStringBuffer) setScreen(screen *Screen) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.screen = screen
 }
 
 // Call this to setup the slice when creating one of these
 func (this *ColorizedStringBuffer) Prepare(capacity int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = make([]*ColorizedString, 0)
 	this.capacity = capacity
 }
@@ -19,28 +43,68 @@ func (this *ColorizedStringBuffer) Prepare(capacity int) {
 // Adds a new element to the end of the stack, and will clip
 // anything over capacity off the bottom.
 func (this *ColorizedStringBuffer) Add(msg *ColorizedString) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = append(this.holder, msg)
 	if len(this.holder) > this.capacity {
 		this.truncateOld()
 	}
+	this.invalidate()
+}
+
+// Sets how many spaces the lines after the first one of a long entry get
+// indented by when it's wrapped, see WrapText.
+func (this *ColorizedStringBuffer) SetWrapIndent(indent int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.wrapIndent = indent
+	this.invalidate()
 }
 
 // Clear the buffer's contents.
 func (this *ColorizedStringBuffer) Clear() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = make([]*ColorizedString, 0)
+	this.invalidate()
 }
 
 // Gets the lines out of the buffer.  This will split any lines that are too long
-// into two (or as many as it takes until they are shorter than line length) lines
-// and then returns the last <lineCount> number of lines.
+// into two (or as many as it takes until they are shorter than line length) lines,
+// breaking between words and at newlines (see WrapText), and then returns the
+// last <lineCount> number of lines.
 func (this *ColorizedStringBuffer) GetContents(lineLength, lineCount int) []*ColorizedString {
+	lines, _ := this.GetScrolledContents(lineLength, lineCount, 0)
+	return lines
+}
+
+// Same as GetContents, but skips the last <scrollBack> lines in order to look
+// further back into the buffer.  The scrollback gets clamped so it can't go past
+// the oldest line, and the value which was actually used is returned along with
+// the lines.
+func (this *ColorizedStringBuffer) GetScrolledContents(lineLength, lineCount, scrollBack int) ([]*ColorizedString, int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	colStringCount := len(this.holder)
 	if lineLength == 0 && colStringCount == 0 {
-		return nil
+		return nil, 0
+	}
+	if lineCount < 0 {
+		lineCount = 0
 	}
+	if scrollBack < 0 {
+		scrollBack = 0
+	}
+
+	// Every message is at least one line, so this is enough messages to
+	// fill the window even when scrolled back.
 	cutOff := 0
-	if lineCount < colStringCount {
-		cutOff = colStringCount - lineCount
+	if lineCount+scrollBack < colStringCount {
+		cutOff = colStringCount - lineCount - scrollBack
 	}
 
 	// Get the "messages" - which can be longer than a line
@@ -49,10 +113,10 @@ func (this *ColorizedStringBuffer) GetContents(lineLength, lineCount int) []*Col
 	// Add everything, splitting any lines that are too long into smaller lines
 	// We'll rechop after
 	for _, colString := range unsplitLines {
-		if len(colString.Text) <= lineLength {
+		if TextWidth(colString.Text) <= lineLength && !strings.Contains(colString.Text, "\n") {
 			splitLines = append(splitLines, colString)
 		} else {
-			newlySplitLines := SplitBufferLines(colString.Text, lineLength)
+			newlySplitLines := WrapText(colString.Text, lineLength, this.wrapIndent)
 			for _, newLine := range newlySplitLines {
 				cs := new(ColorizedString)
 				cs.Text = newLine
@@ -62,16 +126,25 @@ func (this *ColorizedStringBuffer) GetContents(lineLength, lineCount int) []*Col
 		}
 	}
 
-	// now we need to make sure we haven't got more lines than lineCount
-	if len(splitLines) > lineCount {
-		splitLines = splitLines[(len(splitLines) - lineCount):]
+	// Now chop the window we want out of the end, making sure we don't
+	// scroll back further than there are lines
+	if cutOff == 0 && scrollBack > len(splitLines)-lineCount {
+		scrollBack = len(splitLines) - lineCount
+		if scrollBack < 0 {
+			scrollBack = 0
+		}
+	}
+	end := len(splitLines) - scrollBack
+	start := end - lineCount
+	if start < 0 {
+		start = 0
 	}
 
-	return splitLines
-
+	return splitLines[start:end], scrollBack
 }
 
-// Clears oldest colorized strings to get back to capacity
+// Clears oldest colorized strings to get back to capacity.
+// The lock has to be held when calling this.
 func (this *ColorizedStringBuffer) truncateOld() {
 	length := len(this.holder)
 	cutOff := length - this.capacity
@@ -10,29 +10,29 @@ import (
 // To control where it is drawn on the screen, it uses a function value which returns the four corners
 // of a rectangle, which represents it's location on the screen.
 //
-// These shouldn't be created via new() - use the CreateColorizedTextWidget() call instead.
+// These shouldn't be created via new() - use the NewColorizedText() call instead.
 type ColorizedStringWidget struct {
+	themeable
+
 	rect         *Rectangle
-	textColor    termbox.Attribute
-	borderColor  termbox.Attribute
-	bgColor      termbox.Attribute
 	calcFunction CalcFunction
 	buffer       *ColorizedStringBuffer
+	scrollBack   int
 }
 
 // This is the draw call - it takes a buffer type which meets the text buffer interface
 // and draws the text in it to the screen at the positions defined by its rectangle.
-func (this *ColorizedStringWidget) Draw() {
-	if this.rect == nil {
-		this.CalculateSize()
-	}
+func (this *ColorizedStringWidget) Draw(canvas *Canvas) {
+	rect := canvas.GetRect()
+	style := this.getStyle(canvas, STATE_NORMAL)
 
-	this.drawBorderAndBg()
-	lines := this.buffer.GetContents(this.rect.Width()-1, this.rect.Height()-1)
+	this.drawBorderAndBg(canvas, style.Border)
+	var lines []*ColorizedString
+	lines, this.scrollBack = this.buffer.GetScrolledContents(rect.Width()-1, rect.Height()-1, this.scrollBack)
 	linesLen := len(lines)
 	heightMod := 0
 	for i := 0; i < linesLen; i++ {
-		TermboxPrintf(this.rect.X1+1, this.rect.Y2-linesLen+heightMod, lines[i].Color, this.bgColor, lines[i].Text)
+		SurfacePrint(canvas, rect.X1+1, rect.Y2-linesLen+heightMod, fromTermbox(lines[i].Color), style.Text.GetBg(), lines[i].Text)
 		heightMod++
 	}
 }
@@ -41,22 +41,25 @@ func (this *ColorizedStringWidget) Draw() {
 //
 // TODO: probably paint BG colors, thought this may need to be
 // in tandem with the normal Draw function as well.
-func (this *ColorizedStringWidget) drawBorderAndBg() {
+func (this *ColorizedStringWidget) drawBorderAndBg(canvas *Canvas, style Style) {
+	rect := canvas.GetRect()
+	borderColor := style.GetFg()
+	bgColor := style.GetBg()
 
 	// Draw corners
-	termbox.SetCell(this.rect.X1, this.rect.Y1, 0x250C, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y1, 0x2510, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X1, this.rect.Y2, 0x2514, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y2, 0x2518, this.borderColor, this.bgColor)
-
-	for i := this.rect.X1 + 1; i < this.rect.X2; i++ {
-		termbox.SetCell(i, this.rect.Y1, 0x2500, this.borderColor, this.bgColor)
-		termbox.SetCell(i, this.rect.Y2, 0x2500, this.borderColor, this.bgColor)
+	canvas.SetCell(rect.X1, rect.Y1, 0x250C, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y1, 0x2510, borderColor, bgColor)
+	canvas.SetCell(rect.X1, rect.Y2, 0x2514, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y2, 0x2518, borderColor, bgColor)
+
+	for i := rect.X1 + 1; i < rect.X2; i++ {
+		canvas.SetCell(i, rect.Y1, 0x2500, borderColor, bgColor)
+		canvas.SetCell(i, rect.Y2, 0x2500, borderColor, bgColor)
 	}
 
-	for i := this.rect.Y1 + 1; i < this.rect.Y2; i++ {
-		termbox.SetCell(this.rect.X1, i, 0x2502, this.borderColor, this.bgColor)
-		termbox.SetCell(this.rect.X2, i, 0x2502, this.borderColor, this.bgColor)
+	for i := rect.Y1 + 1; i < rect.Y2; i++ {
+		canvas.SetCell(rect.X1, i, 0x2502, borderColor, bgColor)
+		canvas.SetCell(rect.X2, i, 0x2502, borderColor, bgColor)
 	}
 }
 
@@ -78,9 +81,41 @@ func (this *ColorizedStringWidget) Select() {}
 // For the moment do nothing, this is just here to satisfy the interface.s
 func (this *ColorizedStringWidget) Unselect() {}
 
-// This kind of widget cannot be selected.
-// For the moment do nothing, this is just here to satisfy the interface.
-func (this *ColorizedStringWidget) HandleEvents(event interface{}) {}
+// Scrolls the widget back (towards older lines) by a number of lines, or
+// forward when lines is negative.  Scrolling all the way forward goes back
+// to following the newest lines as they come in.
+func (this *ColorizedStringWidget) Scroll(lines int) {
+	this.scrollBack += lines
+	if this.scrollBack < 0 {
+		this.scrollBack = 0
+	}
+	this.invalidate()
+}
+
+// This kind of widget cannot be selected, so the only events it gets are
+// from the mouse - the wheel scrolls through the buffer.
+func (this *ColorizedStringWidget) HandleEvents(event Event) bool {
+	if event.Type != EVENT_MOUSE {
+		return false
+	}
+	if event.Mouse.Action == MOUSE_WHEEL_UP {
+		this.Scroll(WHEEL_SCROLL_LINES)
+	} else if event.Mouse.Action == MOUSE_WHEEL_DOWN {
+		this.Scroll(-WHEEL_SCROLL_LINES)
+	} else {
+		return false
+	}
+	return true
+}
+
+// Internal method for telling the widget what screen it's on, which its
+// buffer gets told too.
+func (this *ColorizedStringWidget) setScreen(screen *Screen) {
+	this.screen = screen
+	if this.buffer != nil {
+		this.buffer.setScreen(screen)
+	}
+}
 
 // Meant to be called when the terminal dimensions are resized, it calls the callback function and
 // refigures out the sizing and positioning of the rectacngle.
@@ -89,13 +124,45 @@ func (this *ColorizedStringWidget) CalculateSize() {
 	this.rect = rect
 }
 
-// A "constructor" function to create new widgets.
-func CreateColorizedTextWidget(textColor, borderColor, bgColor termbox.Attribute, calcFunction CalcFunction, buffer *ColorizedStringBuffer) *ColorizedStringWidget {
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *ColorizedStringWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *ColorizedStringWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
+// A "constructor" function to create new widgets showing what's in a
+// buffer.  They're drawn by their theme and cover the whole screen unless
+// the options say otherwise.
+func NewColorizedText(buffer *ColorizedStringBuffer, options ...WidgetOption) *ColorizedStringWidget {
 	widget := new(ColorizedStringWidget)
-	widget.textColor = textColor
-	widget.bgColor = bgColor
-	widget.borderColor = borderColor
-	widget.calcFunction = calcFunction
+	widget.role = ROLE_DISPLAY
 	widget.buffer = buffer
+	widget.calcFunction = FullScreen
+
+	applyOptions(widget, options)
 	return widget
 }
+
+// A "constructor" function to create new widgets.
+//
+// Deprecated: use NewColorizedText instead.
+func CreateColorizedTextWidget(textColor, borderColor, bgColor termbox.Attribute, calcFunction CalcFunction, buffer *ColorizedStringBuffer) *ColorizedStringWidget {
+	return NewColorizedText(buffer,
+		WithStyle(STATE_NORMAL, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(textColor), fromTermbox(bgColor)),
+			Border: CreateStyle(fromTermbox(borderColor), fromTermbox(bgColor)),
+		}),
+		WithLayout(calcFunction),
+	)
+}
@@ -0,0 +1,161 @@
+package tbuikit
+
+// Describes how much room something in a layout container (see Box and Grid)
+// gets along the container's direction - a fixed number of cells, a
+// percentage of the room there is, or a share of whatever is left over.
+// Any of them can be held between a minimum and maximum size.
+//
+// These are made with Fixed(), Percent() and Flex(), and limited with
+// WithMin() and WithMax():
+//
+//	box.Add(Flex(1).WithMin(10))
+type Constraint struct {
+	Size    int
+	Percent int
+	Flex    int
+	Min     int
+	Max     int
+}
+
+// A constraint taking up exactly size cells.
+func Fixed(size int) Constraint {
+	return Constraint{Size: size}
+}
+
+// A constraint taking up a percentage (0 to 100) of the room in the
+// container, after its padding and gaps.
+func Percent(percent int) Constraint {
+	return Constraint{Percent: percent}
+}
+
+// A constraint sharing whatever room is left once the fixed and percentage
+// ones have been laid out with the other flexible ones, in proportion to
+// their weights.
+func Flex(weight int) Constraint {
+	if weight < 1 {
+		weight = 1
+	}
+	return Constraint{Flex: weight}
+}
+
+// Returns a copy of the constraint which never gets smaller than min cells.
+func (this Constraint) WithMin(min int) Constraint {
+	this.Min = min
+	return this
+}
+
+// Returns a copy of the constraint which never gets bigger than max cells.
+// A max of 0 means there's no limit.
+func (this Constraint) WithMax(max int) Constraint {
+	this.Max = max
+	return this
+}
+
+// Holds a size between the constraint's minimum and maximum
+func (this Constraint) clamp(size int) int {
+	if this.Max > 0 && size > this.Max {
+		size = this.Max
+	}
+	if size < this.Min {
+		size = this.Min
+	}
+	if size < 0 {
+		size = 0
+	}
+	return size
+}
+
+// Lays out constraints one after the other along a line of length cells
+// starting at start, with gap cells between each.  Returns where each one
+// starts and how many cells it gets.
+//
+// Fixed and percentage sizes are worked out first, then the flexible ones
+// split up what's left - any which hit their min or max get it, and the rest
+// is split up again between the others.  When there isn't enough room, the
+// last ones get squeezed (down to nothing if need be) so that nothing ends
+// up outside of the line.
+func layoutConstraints(start, length, gap int, constraints []Constraint) (offsets, sizes []int) {
+	offsets = make([]int, len(constraints))
+	sizes = make([]int, len(constraints))
+	if len(constraints) == 0 {
+		return offsets, sizes
+	}
+
+	room := length - gap*(len(constraints)-1)
+	if room < 0 {
+		room = 0
+	}
+
+	used := 0
+	flexible := make([]int, 0)
+	for i, c := range constraints {
+		if c.Flex > 0 {
+			flexible = append(flexible, i)
+			continue
+		}
+		size := c.Size
+		if c.Percent > 0 {
+			size = room * c.Percent / 100
+		}
+		sizes[i] = c.clamp(size)
+		used += sizes[i]
+	}
+
+	// Keep splitting the leftover room between the flexible constraints,
+	// settling the ones which get clamped, until none of them are
+	for len(flexible) > 0 {
+		left := room - used
+		if left < 0 {
+			left = 0
+		}
+		weights := 0
+		for _, i := range flexible {
+			weights += constraints[i].Flex
+		}
+
+		share := make([]int, len(flexible))
+		given := 0
+		for n, i := range flexible {
+			share[n] = left * constraints[i].Flex / weights
+			given += share[n]
+		}
+		// Hand out what rounding left over, one cell at a time
+		for n := 0; given < left; n = (n + 1) % len(flexible) {
+			share[n]++
+			given++
+		}
+
+		remaining := make([]int, 0)
+		for n, i := range flexible {
+			clamped := constraints[i].clamp(share[n])
+			if clamped != share[n] {
+				sizes[i] = clamped
+				used += clamped
+			} else {
+				remaining = append(remaining, i)
+			}
+		}
+		if len(remaining) == len(flexible) {
+			for n, i := range flexible {
+				sizes[i] = share[n]
+			}
+			break
+		}
+		flexible = remaining
+	}
+
+	// Place them, squeezing whatever doesn't fit
+	pos := start
+	end := start + length
+	for i := range constraints {
+		if pos+sizes[i] > end {
+			sizes[i] = end - pos
+			if sizes[i] < 0 {
+				sizes[i] = 0
+			}
+		}
+		offsets[i] = pos
+		pos += sizes[i] + gap
+	}
+	return offsets, sizes
+}
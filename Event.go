@@ -0,0 +1,155 @@
+package tbuikit
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// An input (or other) event, flowing from the backend through the UI and
+// the screen down to the widgets.  Which fields are meaningful depends on
+// the type:
+//
+//   - EVENT_KEY uses Key (for meta keys) or Ch (for printable keys), plus Mod
+//   - EVENT_MOUSE uses Mouse, plus Mod
+//   - EVENT_RESIZE uses Width and Height
+//   - EVENT_PASTE uses Text
+//   - EVENT_CUSTOM uses Data, which is whatever the application sent
+type Event struct {
+	Type   EventType
+	Key    termbox.Key
+	Ch     rune
+	Mod    Modifier
+	Mouse  MouseEvent
+	Width  int
+	Height int
+	Text   string
+	Data   interface{}
+}
+
+// Gets the key press this event represents, which is what keybindings are
+// looked up by.  Only meaningful for key events.
+func (this Event) GetKeyPress() KeyPress {
+	return KeyPress{Key: this.Key, Ch: this.Ch, Mod: this.Mod}
+}
+
+// Checks if this is a press of a given meta key, without modifiers.
+func (this Event) IsKey(key termbox.Key) bool {
+	return this.Type == EVENT_KEY && this.Ch == 0 && this.Key == key && this.Mod == 0
+}
+
+// Checks if this is a press of a given printable key, without modifiers.
+func (this Event) IsChar(char rune) bool {
+	return this.Type == EVENT_KEY && this.Ch == char && this.Mod == 0
+}
+
+// Gets what the event would have looked like before events were typed -
+// a termbox.Key for meta keys and a rune for printable keys.  Returns nil
+// for anything the old handlers couldn't get, like mouse events or keys
+// pressed along with a modifier.
+func (this Event) GetLegacyValue() interface{} {
+	if this.Type != EVENT_KEY || this.Mod != 0 {
+		return nil
+	}
+	if this.Ch != 0 {
+		return this.Ch
+	}
+	return this.Key
+}
+
+// A key along with its modifiers, used as the key of the keybinding maps.
+// Either Key (for meta keys) or Ch (for printable keys) is set, not both.
+type KeyPress struct {
+	Key termbox.Key
+	Ch  rune
+	Mod Modifier
+}
+
+// Creates a meta key (those of type termbox.Key) event.
+func CreateKeyEvent(key termbox.Key, mod Modifier) Event {
+	return Event{Type: EVENT_KEY, Key: key, Mod: mod}
+}
+
+// Creates a printable key event.
+func CreateCharEvent(char rune, mod Modifier) Event {
+	return Event{Type: EVENT_KEY, Ch: char, Mod: mod}
+}
+
+// Creates a mouse event.
+func CreateMouseEvent(action MouseAction, x, y int) Event {
+	return Event{Type: EVENT_MOUSE, Mouse: MouseEvent{Action: action, X: x, Y: y}}
+}
+
+// Creates a resize event.
+func CreateResizeEvent(width, height int) Event {
+	return Event{Type: EVENT_RESIZE, Width: width, Height: height}
+}
+
+// Creates a paste event, for text which arrived all at once.
+func CreatePasteEvent(text string) Event {
+	return Event{Type: EVENT_PASTE, Text: text}
+}
+
+// Creates an application defined event carrying some data, see UI.PostEvent.
+func CreateCustomEvent(data interface{}) Event {
+	return Event{Type: EVENT_CUSTOM, Data: data}
+}
+
+// Adapters for code written against the untyped events
+
+// Wraps an old style callback so it can be used where an EventHandler is
+// expected.  The callback only gets called for the events it could have
+// gotten before (see Event.GetLegacyValue), with the key or rune as its
+// second argument, and always consumes them.
+func AdaptEventCallback(callback EventCallback) EventHandler {
+	return func(source interface{}, event Event) bool {
+		value := event.GetLegacyValue()
+		if value == nil {
+			return false
+		}
+		callback(source, value)
+		return true
+	}
+}
+
+// The shape of widgets from before events were typed, which took either
+// a termbox.Key or a rune in HandleEvents, and drew in screen coordinates.
+type LegacyWidget interface {
+	Draw(surface Surface)
+	CalculateSize()
+	GetRect() *Rectangle
+	IsSelectable() bool
+	IsSelected() bool
+	Select()
+	Unselect()
+	HandleEvents(interface{})
+}
+
+// Turns a LegacyWidget into a Widget which can be added to screens.  The
+// wrapped widget only gets the events it could have gotten before (see
+// Event.GetLegacyValue).  Keep in mind it's the returned value which the
+// screen knows about, so that's what to pass to things like Screen.Focus.
+func AdaptLegacyWidget(widget LegacyWidget) Widget {
+	return &legacyWidgetAdapter{widget}
+}
+
+// Wraps a LegacyWidget, translating the events on their way in
+type legacyWidgetAdapter struct {
+	LegacyWidget
+}
+
+// Old widgets draw in screen coordinates, so they get a canvas which only
+// clips and doesn't move anything.
+func (this *legacyWidgetAdapter) Draw(canvas *Canvas) {
+	this.LegacyWidget.Draw(canvas.untranslated())
+}
+
+// Passes the event on in the old format, if it has one.  The old widgets
+// had no way of saying they didn't want an event, so they consume all of
+// the ones they get.
+func (this *legacyWidgetAdapter) HandleEvents(event Event) bool {
+	value := event.GetLegacyValue()
+	if value == nil {
+		return false
+	}
+	this.LegacyWidget.HandleEvents(value)
+	return true
+}
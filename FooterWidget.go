@@ -0,0 +1,108 @@
+package tbuikit
+
+// A one line bar listing the keys which currently do something, along with
+// what they do - like "ctrl+s Save  q Quit".  It asks the ui for the bindings
+// in effect every time it's drawn (see UI.GetActiveBindings), so it follows
+// the active screen and the selected widget around.  Only bindings with a
+// description get listed, and whatever doesn't fit is left out.  The keys
+// are drawn in the accent style of its theme (as ROLE_FOOTER).
+//
+// These shouldn't be created via new() - use the NewFooter() call instead.
+type FooterWidget struct {
+	themeable
+
+	ui           *UI
+	calcFunction CalcFunction
+	rect         *Rectangle
+}
+
+// Draws the bindings on the first line of the widget's rectangle.
+func (this *FooterWidget) Draw(canvas *Canvas) {
+	rect := canvas.GetRect()
+	style := this.getStyle(canvas, STATE_NORMAL)
+
+	y := rect.Y1
+	for x := rect.X1; x <= rect.X2; x++ {
+		canvas.SetCell(x, y, ' ', style.Text.GetFg(), style.Text.GetBg())
+	}
+
+	x := rect.X1
+	for _, binding := range this.ui.GetActiveBindings() {
+		description, _ := this.ui.GetBindingDescription(binding)
+		if description == "" {
+			continue
+		}
+
+		keys := binding.GetKeys().String()
+		keysWidth := TextWidth(keys)
+		descriptionWidth := TextWidth(description)
+		if x+keysWidth+descriptionWidth > rect.X2 {
+			break
+		}
+		SurfacePrint(canvas, x, y, style.Accent.GetFg(), style.Accent.GetBg(), keys)
+		SurfacePrint(canvas, x+keysWidth+1, y, style.Text.GetFg(), style.Text.GetBg(), description)
+		x += keysWidth + descriptionWidth + 3
+	}
+}
+
+// Meant to be called when the terminal dimensions are resized, it calls the callback function and
+// refigures out the sizing and positioning of the rectacngle.
+func (this *FooterWidget) CalculateSize() {
+	rect := CreateRectangle(this.calcFunction())
+	this.rect = rect
+}
+
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *FooterWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *FooterWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
+// This widget cannot ever be selectable, so always return false.
+func (this *FooterWidget) IsSelectable() bool {
+	return false
+}
+
+// This widget cannot ever be selectable, so always return false.
+func (this *FooterWidget) IsSelected() bool {
+	return false
+}
+
+// This kind of widget cannot be selected.
+// For the moment do nothing, this is just here to satisfy the interface.
+func (this *FooterWidget) Select() {}
+
+// This kind of widget cannot be selected.
+// For the moment do nothing, this is just here to satisfy the interface.
+func (this *FooterWidget) Unselect() {}
+
+// This kind of widget cannot be selected.
+// It never consumes anything, this is just here to satisfy the interface.
+func (this *FooterWidget) HandleEvents(event Event) bool {
+	return false
+}
+
+// A "constructor" function to create new footers.  The ui is where the
+// bindings come from.  Footers are drawn by their theme and cover the whole
+// screen unless the options say otherwise, so they'll usually want a
+// WithLayout.
+func NewFooter(ui *UI, options ...WidgetOption) *FooterWidget {
+	widget := new(FooterWidget)
+	widget.role = ROLE_FOOTER
+	widget.ui = ui
+	widget.calcFunction = FullScreen
+
+	applyOptions(widget, options)
+	return widget
+}
@@ -0,0 +1,115 @@
+package tbuikit
+
+// A layout container which splits an area given by a CalcFunction into
+// columns and rows, each sized by a Constraint the same way as a Box's slots.
+//
+// Like a box, it hands out CalcFunctions rather than holding widgets, one
+// for each cell (or block of cells) asked for with Cell or Span:
+//
+//	grid := CreateGrid(FullScreen, []Constraint{Fixed(20), Flex(1)}, []Constraint{Flex(1), Fixed(3)})
+//	grid.SetGap(1, 0)
+//	menu := grid.Span(0, 0, 1, 2)
+//	output := grid.Cell(1, 0)
+//	input := grid.Cell(1, 1)
+//
+// These shouldn't be created via new() - use the CreateGrid() call instead.
+type Grid struct {
+	calcFunction CalcFunction
+	columns      []Constraint
+	rows         []Constraint
+	columnGap    int
+	rowGap       int
+
+	paddingTop    int
+	paddingRight  int
+	paddingBottom int
+	paddingLeft   int
+}
+
+// Gets the area of the cell at a column and row, counting from 0 at the top
+// left.
+func (this *Grid) Cell(column, row int) CalcFunction {
+	return this.Span(column, row, 1, 1)
+}
+
+// Gets the area of a block of cells, starting from the one at a column and
+// row and going right and down from it.  The gaps between the cells are part
+// of the block.  Spans running off of the grid get cut down to fit in it.
+func (this *Grid) Span(column, row, columnSpan, rowSpan int) CalcFunction {
+	return func() (int, int, int, int) {
+		return this.getSpan(column, row, columnSpan, rowSpan)
+	}
+}
+
+// Sets how many cells are left empty between each column and each row.
+//
+// The grid doesn't know what screens its cells end up used on, so it can't
+// redraw them - when changing a grid once its widgets are on a screen, call
+// the screen's DoResize to lay them out again.
+func (this *Grid) SetGap(columnGap, rowGap int) {
+	this.columnGap = columnGap
+	this.rowGap = rowGap
+}
+
+// Sets how many cells are left empty around the inside of the grid's area.
+// Like with SetGap, the screens using the grid need a DoResize to pick it up.
+func (this *Grid) SetPadding(top, right, bottom, left int) {
+	this.paddingTop = top
+	this.paddingRight = right
+	this.paddingBottom = bottom
+	this.paddingLeft = left
+}
+
+// Gets how many columns the grid has
+func (this *Grid) GetColumnCount() int {
+	return len(this.columns)
+}
+
+// Gets how many rows the grid has
+func (this *Grid) GetRowCount() int {
+	return len(this.rows)
+}
+
+// Internal method which lays out the grid and gets the corners of a block of
+// cells.
+func (this *Grid) getSpan(column, row, columnSpan, rowSpan int) (x1, x2, y1, y2 int) {
+	x1, x2, y1, y2 = this.calcFunction()
+	x1 += this.paddingLeft
+	x2 -= this.paddingRight
+	y1 += this.paddingTop
+	y2 -= this.paddingBottom
+
+	columnOffsets, columnSizes := layoutConstraints(x1, x2-x1+1, this.columnGap, this.columns)
+	rowOffsets, rowSizes := layoutConstraints(y1, y2-y1+1, this.rowGap, this.rows)
+	x1, x2 = spanOf(columnOffsets, columnSizes, column, columnSpan, x1)
+	y1, y2 = spanOf(rowOffsets, rowSizes, row, rowSpan, y1)
+	return x1, x2, y1, y2
+}
+
+// Gets the first and last position covered by count slots from first, given
+// where each slot starts and how big it is.  An empty span (which ends right
+// before it starts) at fallback is given if first is out of range.
+func spanOf(offsets, sizes []int, first, count, fallback int) (int, int) {
+	if first < 0 || first >= len(offsets) {
+		return fallback, fallback - 1
+	}
+	last := first + count - 1
+	if last >= len(offsets) {
+		last = len(offsets) - 1
+	}
+	if last < first {
+		last = first
+	}
+	return offsets[first], offsets[last] + sizes[last] - 1
+}
+
+// A "constructor" function to create a grid within the area calcFunction
+// gives, with a constraint for the width of each column and the height of
+// each row.
+func CreateGrid(calcFunction CalcFunction, columns, rows []Constraint) *Grid {
+	grid := new(Grid)
+	grid.calcFunction = calcFunction
+	grid.columns = columns
+	grid.rows = rows
+	return grid
+}
@@ -0,0 +1,331 @@
+package tbuikit
+
+import (
+	"sort"
+)
+
+// A key press bound to a handler at some level of the interface (the ui, a
+// screen, a layer or a widget).
+//
+// Events travel in two phases.  In the capture phase they go from the top
+// down - ui, screen, layer, then the widget - and in the bubble phase they
+// come back up from the widget to the ui.  Capture bindings run on the way
+// down, before anything underneath gets a chance at the key, and the others
+// run on the way back up, only if nothing underneath consumed it.  This is
+// what lets a text input use a key which the screen also binds as a fallback.
+//
+// Sequence bindings (see AddKeySequenceHandler) have Sequence set instead of
+// Press.  They don't have phases - the deepest level with a binding for the
+// sequence gets it.  Bindings made for a named action (see Keymap) have the
+// action's name in Action.
+//
+// The methods adding bindings return them, so they can be given a description
+// and a group for the help overlay and footer (see UI.ShowHelp), like:
+//
+//	screen.AddCharKeyCallback('q', quit).Describe("Quit", "General")
+type KeyBinding struct {
+	Press       KeyPress
+	Sequence    KeySequence
+	Handler     EventHandler
+	Capture     bool
+	Action      string
+	Description string
+	Group       string
+
+	// Marks whatever the binding was added to as dirty, since the help
+	// overlay and the footer list it
+	redraw func()
+}
+
+// Sets the binding's description and group, returning the binding.
+func (this *KeyBinding) Describe(description, group string) *KeyBinding {
+	this.Description = description
+	this.Group = group
+	if this.redraw != nil {
+		this.redraw()
+	}
+	return this
+}
+
+// Gets the keys which trigger the binding, as a sequence (of one key, for
+// the bindings which aren't sequences).
+func (this *KeyBinding) GetKeys() KeySequence {
+	if this.Sequence != nil {
+		return this.Sequence
+	}
+	return KeySequence{this.Press}
+}
+
+// Implemented by widgets which can have keys bound to them
+type keyBindingHolder interface {
+	getKeyBindings() map[KeyPress]*KeyBinding
+	getKeySequences() []*KeyBinding
+}
+
+// The bindings of a widget, embedded in the widgets which can have keys bound
+// to them (the way themeable is) so they all get the same binding methods.
+// The owner is the widget it's embedded in, which gets passed to the handlers.
+type keyBindings struct {
+	owner             Widget
+	widgetKeyBindings map[KeyPress]*KeyBinding
+	keySequences      []*KeyBinding
+	actions           map[string]*namedAction
+}
+
+// Take widget level key press handler function.  It only runs if the
+// widget's own handling of the key didn't consume it (and for containers,
+// if the widget inside of it the key went to didn't either).
+func (this *keyBindings) AddKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler})
+}
+
+// Take widget level key press handler function which runs before the
+// widget's own handling of the key (and for containers, before the widget
+// inside of it the key is going to).
+func (this *keyBindings) AddCaptureKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler, Capture: true})
+}
+
+// Binds a sequence of key presses (like "ctrl+x ctrl+s", or "g g") to a widget
+// level handler, which runs once the whole sequence has been typed.  While
+// it's being typed the keys don't go anywhere else.  These only get used
+// while the widget (or one inside of it) is selected.
+func (this *keyBindings) AddKeySequenceHandler(sequence KeySequence, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Sequence: sequence, Handler: handler})
+}
+
+// Internal method for adding a binding.  Returns the binding it was given.
+func (this *keyBindings) addKeyBinding(binding *KeyBinding) *KeyBinding {
+	binding.redraw = this.invalidateOwner
+	if binding.Sequence != nil {
+		this.keySequences = addSequenceBinding(this.keySequences, binding)
+	} else {
+		this.widgetKeyBindings[binding.Press] = binding
+	}
+	this.warnAddedShadowing([]*KeyBinding{binding})
+	this.invalidateOwner()
+	return binding
+}
+
+// Gets all of the widget's bindings - the single key ones sorted by their
+// keys, then the sequences in the order they were added.
+func (this *keyBindings) GetBindings() []*KeyBinding {
+	return listBindings(this.widgetKeyBindings, this.keySequences)
+}
+
+// Removes one of the widget's bindings, as returned when it was added.
+// Nothing happens if it isn't bound anymore.
+func (this *keyBindings) RemoveBinding(binding *KeyBinding) {
+	this.keySequences = removeKeyBinding(this.widgetKeyBindings, this.keySequences, binding)
+	this.invalidateOwner()
+}
+
+// Internal method for getting the widget's key bindings.
+func (this *keyBindings) getKeyBindings() map[KeyPress]*KeyBinding {
+	return this.widgetKeyBindings
+}
+
+// Internal method for getting the widget's key sequence bindings.
+func (this *keyBindings) getKeySequences() []*KeyBinding {
+	return this.keySequences
+}
+
+// Registers a named action on the widget, triggered by keys given as a key
+// sequence spec (like "ctrl+s", see ParseKeySequence) or "" for no keys yet.
+// Keymaps refer to actions by name, so the keys can be changed later on with
+// BindAction or ApplyKeymap.  The bindings run when nothing underneath
+// consumed the key, like the ones added with AddKeyHandler.
+func (this *keyBindings) AddAction(name string, keys string, handler EventHandler) error {
+	var err error
+	this.keySequences, err = addAction(this.getActions(), this.widgetKeyBindings, this.keySequences, name, keys, handler, this.invalidateOwner)
+	if err == nil {
+		this.warnAddedShadowing(this.actions[name].bindings)
+	}
+	return err
+}
+
+// Replaces the keys bound to one of the widget's actions.  Calling this
+// with no keys leaves the action unbound.
+func (this *keyBindings) BindAction(name string, keys ...string) error {
+	var err error
+	this.keySequences, err = bindAction(this.getActions(), this.widgetKeyBindings, this.keySequences, name, keys)
+	if err == nil {
+		this.warnAddedShadowing(this.actions[name].bindings)
+	}
+	return err
+}
+
+// Rebinds the widget's actions which are in the keymap.  The widgets inside
+// of a container get the keymap from the screen or layer it's on.
+func (this *keyBindings) ApplyKeymap(keymap Keymap) error {
+	var err error
+	this.keySequences, err = applyKeymap(this.getActions(), this.widgetKeyBindings, this.keySequences, keymap)
+	this.warnAddedShadowing(getKeymapBindings(this.actions, keymap))
+	return err
+}
+
+// Internal method for getting the widget's actions, which are only set up
+// once the first one gets registered.
+func (this *keyBindings) getActions() map[string]*namedAction {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	return this.actions
+}
+
+// Internal method which warns in debug mode about bindings just added to the
+// widget shadowing, or being shadowed by, ones around it.  This goes through
+// the screen the widget is on, so it waits until the widget gets added to one
+// (which checks all of its bindings).
+func (this *keyBindings) warnAddedShadowing(added []*KeyBinding) {
+	linked, ok := this.owner.(interface{ getScreen() *Screen })
+	if ok && linked.getScreen() != nil {
+		linked.getScreen().warnWidgetShadowing(this.owner, added)
+	}
+}
+
+// Internal method which marks the widget as dirty, since the footer and the
+// help overlay list its bindings.  (It's not called invalidate, which would
+// hide the widget's own.)
+func (this *keyBindings) invalidateOwner() {
+	owner, ok := this.owner.(interface{ invalidate() })
+	if ok {
+		owner.invalidate()
+	}
+}
+
+// A "constructor" function for the bindings of a widget.
+func createKeyBindings(owner Widget) keyBindings {
+	return keyBindings{owner: owner, widgetKeyBindings: make(map[KeyPress]*KeyBinding)}
+}
+
+// One level of the interface the bindings get looked up in, along with what
+// gets passed to their handlers.  The depth is 0 for the ui, 1 for screens
+// and layers and 2 for widgets, plus one for each container they're inside of.
+type bindingLevel struct {
+	source    interface{}
+	depth     int
+	bindings  map[KeyPress]*KeyBinding
+	sequences []*KeyBinding
+}
+
+// Internal function which runs the binding for an event's key press, if
+// there's one for the given phase.  Returns whether or not the event was
+// consumed.
+func runKeyBinding(bindings map[KeyPress]*KeyBinding, source interface{}, event Event, capture bool) bool {
+	if event.Type != EVENT_KEY {
+		return false
+	}
+	binding := bindings[event.GetKeyPress()]
+	if binding == nil || binding.Capture != capture {
+		return false
+	}
+	return binding.Handler(source, event)
+}
+
+// Internal function which adds a sequence binding to a slice of them,
+// replacing the binding for the same sequence if there's already one.
+func addSequenceBinding(bindings []*KeyBinding, binding *KeyBinding) []*KeyBinding {
+	for i, existing := range bindings {
+		if existing.Sequence.Equals(binding.Sequence) {
+			bindings[i] = binding
+			return bindings
+		}
+	}
+	return append(bindings, binding)
+}
+
+// Gets the bindings of a map sorted by their keys, so they're always
+// listed in the same order.
+func sortBindings(bindings map[KeyPress]*KeyBinding) []*KeyBinding {
+	sorted := make([]*KeyBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		sorted = append(sorted, binding)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Press.String() < sorted[j].Press.String()
+	})
+	return sorted
+}
+
+// Gets all of a level's bindings - the single key ones sorted by their keys,
+// then the sequences in the order they were added.
+func listBindings(bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding) []*KeyBinding {
+	return append(sortBindings(bindings), sequences...)
+}
+
+// Gets the level a widget's bindings are on, if it can have any.
+func getWidgetBindingLevel(widget Widget, depth int) (bindingLevel, bool) {
+	holder, ok := widget.(keyBindingHolder)
+	if !ok {
+		return bindingLevel{}, false
+	}
+	return bindingLevel{widget, depth, holder.getKeyBindings(), holder.getKeySequences()}, true
+}
+
+// Checks whether or not a binding on some level runs instead of another
+// binding for the same keys on a different level.  Sequences get looked up
+// before anything else, deepest level first.  Otherwise capture bindings go
+// from the top down and come before all of the others, which go from the
+// bottom up.
+func shadows(binding *KeyBinding, depth int, other *KeyBinding, otherDepth int) bool {
+	if depth == otherDepth || !binding.GetKeys().Equals(other.GetKeys()) {
+		return false
+	}
+	if (binding.Sequence != nil) != (other.Sequence != nil) {
+		return binding.Sequence != nil
+	}
+	if binding.Sequence != nil {
+		return depth > otherDepth
+	}
+	if binding.Capture != other.Capture {
+		return binding.Capture
+	}
+	if binding.Capture {
+		return depth < otherDepth
+	}
+	return depth > otherDepth
+}
+
+// Internal function which warns in debug mode (see SetDebugLog) about new
+// bindings on a level which shadow, or are shadowed by, bindings on other
+// levels.  These are easy to miss, since the shadowed binding just never runs.
+func warnShadowing(level bindingLevel, added []*KeyBinding, others []bindingLevel) {
+	if debugLog == nil {
+		return
+	}
+	for _, binding := range added {
+		for _, other := range others {
+			for _, existing := range listBindings(other.bindings, other.sequences) {
+				keys := binding.GetKeys().String()
+				if shadows(binding, level.depth, existing, other.depth) {
+					debugf("%T binding for %q shadows the %T one", level.source, keys, other.source)
+				} else if shadows(existing, other.depth, binding, level.depth) {
+					debugf("%T binding for %q is shadowed by the %T one", level.source, keys, other.source)
+				}
+			}
+		}
+	}
+}
+
+// Appends the levels of the widgets which can have bindings, including the
+// ones inside of containers.
+func appendWidgetBindingLevels(levels []bindingLevel, widgets []Widget) []bindingLevel {
+	return appendNestedBindingLevels(levels, widgets, 2)
+}
+
+// Internal function which appends the levels of a slice of widgets at some
+// depth, and the ones of the widgets inside of containers one deeper.
+func appendNestedBindingLevels(levels []bindingLevel, widgets []Widget, depth int) []bindingLevel {
+	for _, w := range widgets {
+		level, ok := getWidgetBindingLevel(w, depth)
+		if ok {
+			levels = append(levels, level)
+		}
+		container, ok := w.(Container)
+		if ok {
+			levels = appendNestedBindingLevels(levels, container.GetChildren(), depth+1)
+		}
+	}
+	return levels
+}
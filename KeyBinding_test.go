@@ -0,0 +1,70 @@
+package tbuikit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Turns on debug mode for the rest of the test, returning where the warnings
+// end up.
+func captureDebugLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	SetDebugLog(&buf)
+	t.Cleanup(func() {
+		SetDebugLog(nil)
+	})
+	return &buf
+}
+
+func noop(interface{}, Event) bool {
+	return true
+}
+
+func TestShadowingWarnsForBindingsAddedAfterTheWidget(t *testing.T) {
+	screen := new(Screen)
+	screen.AddCaptureKeyHandler(KeyPress{Ch: 'x'}, noop)
+	button := NewButton("Go")
+	screen.AddWidget(button)
+
+	logged := captureDebugLog(t)
+	button.AddKeyHandler(KeyPress{Ch: 'x'}, noop)
+	if !strings.Contains(logged.String(), `*tbuikit.ButtonWidget binding for "x" is shadowed by the *tbuikit.Screen one`) {
+		t.Errorf("expected a warning about the late binding, got %q", logged.String())
+	}
+}
+
+func TestShadowingWarnsForActionsInsideOfPanels(t *testing.T) {
+	screen := new(Screen)
+	panel := NewPanel("Options")
+	panel.AddCaptureKeyHandler(MustParseKeyPress("ctrl+s"), noop)
+	input := NewTextInput(new(TextInputBuffer))
+	panel.AddWidget(input)
+	screen.AddWidget(panel)
+
+	logged := captureDebugLog(t)
+	err := input.AddAction("save", "ctrl+s", noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logged.String(), `*tbuikit.TextInputWidget binding for "ctrl+s" is shadowed by the *tbuikit.PanelWidget one`) {
+		t.Errorf("expected a warning about the action, got %q", logged.String())
+	}
+}
+
+func TestShadowingWarnsForWidgetsAddedToPanelsOnAScreen(t *testing.T) {
+	screen := new(Screen)
+	screen.AddCaptureKeyHandler(KeyPress{Ch: 'q'}, noop)
+	panel := NewPanel("Options")
+	screen.AddWidget(panel)
+
+	logged := captureDebugLog(t)
+	button := NewButton("Go")
+	button.AddKeyHandler(KeyPress{Ch: 'q'}, noop)
+	panel.AddWidget(button)
+	if strings.Count(logged.String(), "shadowed by the *tbuikit.Screen one") != 1 {
+		t.Errorf("expected one warning about the new child, got %q", logged.String())
+	}
+}
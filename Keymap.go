@@ -0,0 +1,241 @@
+package tbuikit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Returned when binding keys to an action which was never registered
+var ErrUnknownAction = errors.New("tbuikit: unknown action")
+
+// Returned when binding keys to an action which are already bound to
+// something else on the same level with AddKeyHandler and friends
+var ErrKeyConflict = errors.New("tbuikit: keys already bound")
+
+// Maps action names to the key sequences (in the format ParseKeySequence
+// takes) which trigger them.  An action mapped to no keys gets unbound.
+//
+// In a JSON keymap file each action maps to either a single spec or a list
+// of them:
+//
+//	{
+//	    "save": "ctrl+s",
+//	    "quit": ["ctrl+q", "ctrl+x ctrl+c"],
+//	    "help": ""
+//	}
+type Keymap map[string][]string
+
+// Parses the JSON format of a keymap, checking every key spec along the way.
+func ParseKeymap(data []byte) (Keymap, error) {
+	raw := make(map[string]json.RawMessage)
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	keymap := make(Keymap)
+	for name, value := range raw {
+		var specs []string
+		var spec string
+		if json.Unmarshal(value, &spec) == nil {
+			if spec != "" {
+				specs = append(specs, spec)
+			}
+		} else if err := json.Unmarshal(value, &specs); err != nil {
+			return nil, fmt.Errorf("tbuikit: keymap action %q: %w", name, err)
+		}
+
+		for _, spec := range specs {
+			_, err := ParseKeySequence(spec)
+			if err != nil {
+				return nil, fmt.Errorf("tbuikit: keymap action %q: %w", name, err)
+			}
+		}
+		keymap[name] = specs
+	}
+	return keymap, nil
+}
+
+// Reads and parses a JSON keymap file, see ParseKeymap.
+func LoadKeymap(path string) (Keymap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeymap(data)
+}
+
+// Implemented by anything (the widgets, mostly) which can have a keymap
+// applied to it
+type keymapApplier interface {
+	ApplyKeymap(keymap Keymap) error
+}
+
+// An action registered at some level, along with the bindings which
+// currently trigger it
+type namedAction struct {
+	name     string
+	handler  EventHandler
+	bindings []*KeyBinding
+
+	// Marks the level the action is on as dirty, see KeyBinding
+	redraw func()
+}
+
+// The functions below do the work of the action methods found on every level
+// of the interface.  They take the level's bindings map and sequence bindings,
+// and return the sequence bindings since those might have to be reallocated.
+
+// Internal function for registering an action and binding it to its
+// default keys (if there are any).  redraw marks the level as dirty.
+func addAction(actions map[string]*namedAction, bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding,
+	name, keys string, handler EventHandler, redraw func()) ([]*KeyBinding, error) {
+
+	old := actions[name]
+	if old != nil {
+		for _, binding := range old.bindings {
+			sequences = removeKeyBinding(bindings, sequences, binding)
+		}
+	}
+	actions[name] = &namedAction{name: name, handler: handler, redraw: redraw}
+
+	specs := make([]string, 0)
+	if keys != "" {
+		specs = append(specs, keys)
+	}
+	return bindAction(actions, bindings, sequences, name, specs)
+}
+
+// Internal function which replaces the keys bound to an action.  Nothing
+// changes if any of the specs is invalid, or if it's already bound to a
+// handler which isn't an action - replacing that one would quietly break
+// whatever the application bound it to.  Keys bound to other actions just
+// move over to this one.
+func bindAction(actions map[string]*namedAction, bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding,
+	name string, specs []string) ([]*KeyBinding, error) {
+
+	action := actions[name]
+	if action == nil {
+		return sequences, fmt.Errorf("%w: %q", ErrUnknownAction, name)
+	}
+
+	parsed := make([]KeySequence, 0, len(specs))
+	for _, spec := range specs {
+		sequence, err := ParseKeySequence(spec)
+		if err != nil {
+			return sequences, err
+		}
+		parsed = append(parsed, sequence)
+
+		existing := findKeyBinding(bindings, sequences, sequence)
+		if existing != nil && existing.Action == "" {
+			return sequences, fmt.Errorf("%w: %q for action %q", ErrKeyConflict, sequence.String(), name)
+		}
+	}
+
+	for _, binding := range action.bindings {
+		sequences = removeKeyBinding(bindings, sequences, binding)
+	}
+	action.bindings = nil
+
+	for _, sequence := range parsed {
+		binding := &KeyBinding{Action: name, Handler: action.handler, redraw: action.redraw}
+		if len(sequence) == 1 {
+			binding.Press = sequence[0]
+			bindings[binding.Press] = binding
+		} else {
+			binding.Sequence = sequence
+			sequences = addSequenceBinding(sequences, binding)
+		}
+		action.bindings = append(action.bindings, binding)
+	}
+	return sequences, nil
+}
+
+// Internal function which rebinds every action of a level which is in the
+// keymap.  Actions the level doesn't have are skipped, since they probably
+// belong to some other level.
+func applyKeymap(actions map[string]*namedAction, bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding,
+	keymap Keymap) ([]*KeyBinding, error) {
+
+	// Go through the actions in order so that if two of them are given
+	// the same key, it's always the same one which wins
+	names := make([]string, 0, len(keymap))
+	for name := range keymap {
+		if actions[name] != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var err error
+	for _, name := range names {
+		sequences, err = bindAction(actions, bindings, sequences, name, keymap[name])
+		if err != nil {
+			return sequences, err
+		}
+	}
+	return sequences, nil
+}
+
+// Internal function for getting the bindings of a level's actions which are
+// in the keymap, to check the ones it just bound for shadowing.
+func getKeymapBindings(actions map[string]*namedAction, keymap Keymap) []*KeyBinding {
+	bindings := make([]*KeyBinding, 0)
+	for name := range keymap {
+		if actions[name] != nil {
+			bindings = append(bindings, actions[name].bindings...)
+		}
+	}
+	return bindings
+}
+
+// Internal function which finds the binding for some keys on a level, or
+// returns nil if they aren't bound.
+func findKeyBinding(bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding, sequence KeySequence) *KeyBinding {
+	if len(sequence) == 1 {
+		return bindings[sequence[0]]
+	}
+	for _, existing := range sequences {
+		if existing.Sequence.Equals(sequence) {
+			return existing
+		}
+	}
+	return nil
+}
+
+// Internal function which removes a binding from a level, if it's still
+// there - it could have been replaced by another binding for the same keys.
+func removeKeyBinding(bindings map[KeyPress]*KeyBinding, sequences []*KeyBinding, binding *KeyBinding) []*KeyBinding {
+	if binding.Sequence == nil {
+		if bindings[binding.Press] == binding {
+			delete(bindings, binding.Press)
+		}
+		return sequences
+	}
+
+	for i, existing := range sequences {
+		if existing == binding {
+			return append(sequences[:i], sequences[i+1:]...)
+		}
+	}
+	return sequences
+}
+
+// Internal function which applies a keymap to the widgets which can take one,
+// including the ones inside of containers.
+func applyKeymapToWidgets(widgets []Widget, keymap Keymap) error {
+	for _, w := range flattenWidgets(widgets) {
+		applier, ok := w.(keymapApplier)
+		if ok {
+			err := applier.ApplyKeymap(keymap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
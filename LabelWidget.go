@@ -5,15 +5,17 @@ import (
 )
 
 // A widget which prints text to the screen, like a button but deliberately can't
-// be selected.
+// be selected.  Its colors come from its theme (as ROLE_LABEL), unless it's
+// given its own with SetStyle (which is what CreateLabelWidget does).
+//
+// These shouldn't be created via new() - use the NewLabel() call instead.
 type LabelWidget struct {
+	themeable
+
 	labelText    string
 	textPosition ScreenPosition
 	drawBorders  bool
-
-	textColor   termbox.Attribute
-	borderColor termbox.Attribute
-	bgColor     termbox.Attribute
+	fillBg       bool
 
 	calcFunction CalcFunction
 	rect         *Rectangle
@@ -21,65 +23,82 @@ type LabelWidget struct {
 
 // Draw the label every iteration of the main loop.  Figure out where to put the button text within the label,
 // make sure the borders get drawn and then draw the text in the figured out location.
-func (this *LabelWidget) Draw() {
-	if this.rect == nil {
-		this.CalculateSize()
+func (this *LabelWidget) Draw(canvas *Canvas) {
+	rect := canvas.GetRect()
+	style := this.getStyle(canvas, STATE_NORMAL)
+
+	if this.fillBg {
+		this.fillBackground(canvas, style.Text)
 	}
 
 	if this.drawBorders {
-		this.drawBorderAndBg()
+		this.drawBorderAndBg(canvas, style.Border)
 	}
 
 	// Decide where the label text should be drawn.  Keep in mind
-	// the Termboxprintf function still prints a string left-to-right,
+	// the SurfacePrint function still prints a string left-to-right,
 	// so the X coord is the first rune of the string and the it advances
 	// to the right.
 	var x, y int
 
 	if this.textPosition == TOP_LEFT {
-		y = this.rect.Y1 + 1
-		x = this.rect.X1 + 1
+		y = rect.Y1 + 1
+		x = rect.X1 + 1
 	} else if this.textPosition == TOP_RIGHT {
-		y = this.rect.Y1 + 1
-		x = this.rect.X2 - len(this.labelText) // - 1?
+		y = rect.Y1 + 1
+		x = rect.X2 - TextWidth(this.labelText) // - 1?
 	} else if this.textPosition == BOTTOM_LEFT {
-		y = this.rect.Y2 - 1
-		x = this.rect.X1 + 1
+		y = rect.Y2 - 1
+		x = rect.X1 + 1
 	} else if this.textPosition == BOTTOM_RIGHT {
-		y = this.rect.Y2 - 1
-		x = this.rect.X2 - len(this.labelText)
+		y = rect.Y2 - 1
+		x = rect.X2 - TextWidth(this.labelText)
 	} else {
 		// default to center
-		y = this.rect.Y2 - (this.rect.Height() / 2)
-		x = this.rect.X2 - (this.rect.Width() / 2) - (len(this.labelText) / 2)
+		y = rect.Y2 - (rect.Height() / 2)
+		x = rect.X2 - (rect.Width() / 2) - (TextWidth(this.labelText) / 2)
 	}
 
-	TermboxPrintf(x, y, this.textColor, this.bgColor, this.labelText)
+	// Text too long for the label gets cut off at the border
+	inside := canvas
+	if this.drawBorders {
+		inside = canvas.Clip(CreateRectangle(rect.X1+1, rect.X2-1, rect.Y1+1, rect.Y2-1))
+	}
+	SurfacePrint(inside, x, y, style.Text.GetFg(), style.Text.GetBg(), this.labelText)
 }
 
 // This draws the border lines around the widget
 //
 // TODO: probably paint BG colors, thought this may need to be
 // in tandem with the normal Draw function as well.
-func (this *LabelWidget) drawBorderAndBg() {
+func (this *LabelWidget) drawBorderAndBg(canvas *Canvas, style Style) {
+	rect := canvas.GetRect()
+	borderColor := style.GetFg()
+	bgColor := style.GetBg()
 
 	// Draw corners
-	termbox.SetCell(this.rect.X1, this.rect.Y1, 0x250C, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y1, 0x2510, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X1, this.rect.Y2, 0x2514, this.borderColor, this.bgColor)
-	termbox.SetCell(this.rect.X2, this.rect.Y2, 0x2518, this.borderColor, this.bgColor)
-
-	for i := this.rect.X1 + 1; i < this.rect.X2; i++ {
-		termbox.SetCell(i, this.rect.Y1, 0x2500, this.borderColor, this.bgColor)
-		termbox.SetCell(i, this.rect.Y2, 0x2500, this.borderColor, this.bgColor)
+	canvas.SetCell(rect.X1, rect.Y1, 0x250C, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y1, 0x2510, borderColor, bgColor)
+	canvas.SetCell(rect.X1, rect.Y2, 0x2514, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y2, 0x2518, borderColor, bgColor)
+
+	for i := rect.X1 + 1; i < rect.X2; i++ {
+		canvas.SetCell(i, rect.Y1, 0x2500, borderColor, bgColor)
+		canvas.SetCell(i, rect.Y2, 0x2500, borderColor, bgColor)
 	}
 
-	for i := this.rect.Y1 + 1; i < this.rect.Y2; i++ {
-		termbox.SetCell(this.rect.X1, i, 0x2502, this.borderColor, this.bgColor)
-		termbox.SetCell(this.rect.X2, i, 0x2502, this.borderColor, this.bgColor)
+	for i := rect.Y1 + 1; i < rect.Y2; i++ {
+		canvas.SetCell(rect.X1, i, 0x2502, borderColor, bgColor)
+		canvas.SetCell(rect.X2, i, 0x2502, borderColor, bgColor)
 	}
 }
 
+// Blanks out the whole rectangle in the background color, hiding
+// whatever was drawn underneath it.
+func (this *LabelWidget) fillBackground(canvas *Canvas, style Style) {
+	canvas.Fill(' ', style.GetFg(), style.GetBg())
+}
+
 // Meant to be called when the terminal dimensions are resized, it calls the callback function and
 // refigures out the sizing and positioning of the rectacngle.
 func (this *LabelWidget) CalculateSize() {
@@ -87,6 +106,23 @@ func (this *LabelWidget) CalculateSize() {
 	this.rect = rect
 }
 
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *LabelWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *LabelWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
 // This widget cannot ever be selectable, so always return false.
 func (this *LabelWidget) IsSelectable() bool {
 	return false
@@ -106,28 +142,64 @@ func (this *LabelWidget) Select() {}
 func (this *LabelWidget) Unselect() {}
 
 // This kind of widget cannot be selected.
-// For the moment do nothing, this is just here to satisfy the interface.
-func (this *LabelWidget) HandleEvents(event interface{}) {}
+// It never consumes anything, this is just here to satisfy the interface.
+func (this *LabelWidget) HandleEvents(event Event) bool {
+	return false
+}
+
+// Sets whether or not the label paints its whole rectangle in its background
+// color before drawing, rather than only the text and borders.  This is what
+// makes a label usable as the backdrop of a popup.
+func (this *LabelWidget) SetFillBackground(fill bool) {
+	this.fillBg = fill
+	this.invalidate()
+}
+
+// Sets whether or not the border gets drawn around the widget.
+func (this *LabelWidget) SetDrawBorders(draw bool) {
+	this.drawBorders = draw
+	this.invalidate()
+}
 
 // Setter for the label's displayed text.
 func (this *LabelWidget) SetText(text string) {
 	this.labelText = text
+	this.invalidate()
 }
 
-// A "constructor" function to create new widgets.
-func CreateLabelWidget(text string, drawBor bool, textPos ScreenPosition, textCol, bgCol, borCol termbox.Attribute,
-	calcFunction CalcFunction) *LabelWidget {
+// Sets where the text goes within the widget.
+func (this *LabelWidget) SetTextPosition(position ScreenPosition) {
+	this.textPosition = position
+	this.invalidate()
+}
 
+// A "constructor" function to create new labels.  Labels start out without
+// a border, with their text in the top left corner, drawn by their theme and
+// covering the whole screen - the options change that.
+func NewLabel(text string, options ...WidgetOption) *LabelWidget {
 	widget := new(LabelWidget)
+	widget.role = ROLE_LABEL
 	widget.labelText = text
-	widget.textPosition = textPos
-	widget.drawBorders = drawBor
+	widget.textPosition = TOP_LEFT
+	widget.calcFunction = FullScreen
 
-	widget.textColor = textCol
-	widget.bgColor = bgCol
-	widget.borderColor = borCol
+	applyOptions(widget, options)
+	return widget
+}
 
-	widget.calcFunction = calcFunction
+// A "constructor" function to create new widgets.
+//
+// Deprecated: use NewLabel instead.
+func CreateLabelWidget(text string, drawBor bool, textPos ScreenPosition, textCol, bgCol, borCol termbox.Attribute,
+	calcFunction CalcFunction) *LabelWidget {
 
-	return widget
+	return NewLabel(text,
+		WithBorder(drawBor),
+		WithTextPosition(textPos),
+		WithStyle(STATE_NORMAL, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(textCol), fromTermbox(bgCol)),
+			Border: CreateStyle(fromTermbox(borCol), fromTermbox(bgCol)),
+		}),
+		WithLayout(calcFunction),
+	)
 }
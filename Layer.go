@@ -0,0 +1,230 @@
+package tbuikit
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// A layer of widgets which gets drawn on top of a screen's own widgets, for
+// things like popups and dialogs.  Layers are stacked with Screen.PushLayer,
+// and the last one pushed is drawn last (on top).
+//
+// A modal layer captures the input: while it's up, key events go to it (its
+// own keybindings first, then its selected widget) instead of the screen and
+// its widgets, and only its widgets can be selected or clicked.  The widget
+// which was selected before the layer was pushed gets selected again when
+// it's removed.  UI level keybindings still work, so a global quit key can't
+// get trapped by a dialog.
+//
+// These shouldn't be created via new() - use the CreateLayer() call instead.
+type Layer struct {
+	widgets          []Widget
+	modal            bool
+	dimBackground    bool
+	layerKeyBindings map[KeyPress]*KeyBinding
+	keySequences     []*KeyBinding
+	actions          map[string]*namedAction
+
+	screen            *Screen
+	previousSelection Widget
+}
+
+// Adds a new widget to the layer
+func (this *Layer) AddWidget(widget Widget) {
+	this.widgets = append(this.widgets, widget)
+	if this.screen != nil {
+		linkToScreen(widget, this.screen)
+		this.screen.warnAddedWidgetShadowing(widget)
+	}
+	this.invalidate()
+}
+
+// Internal method for marking the screen the layer is pushed on as dirty,
+// or every running ui if it isn't pushed.
+func (this *Layer) invalidate() {
+	if this.screen != nil {
+		this.screen.Invalidate()
+	} else {
+		Invalidate()
+	}
+}
+
+// Checks if this layer captures the input
+func (this *Layer) IsModal() bool {
+	return this.modal
+}
+
+// Gets the screen the layer is pushed on, or nil if it isn't.
+func (this *Layer) GetScreen() *Screen {
+	return this.screen
+}
+
+// Takes the layer off of the screen it was pushed on.
+func (this *Layer) Dismiss() {
+	if this.screen != nil {
+		this.screen.RemoveLayer(this)
+	}
+}
+
+// Take layer level key press handler function, which runs when the selected
+// widget doesn't consume the key.  These only get used while the layer is
+// the topmost modal one.
+func (this *Layer) AddKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler})
+}
+
+// Take layer level key press handler function, which runs before the
+// selected widget gets the key.  These only get used while the layer is
+// the topmost modal one.
+func (this *Layer) AddCaptureKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler, Capture: true})
+}
+
+// Binds a sequence of key presses (like "ctrl+x ctrl+s", or "g g") to a layer
+// level handler, which runs once the whole sequence has been typed.  While
+// it's being typed the keys don't go anywhere else.  These only get used
+// while the layer is the topmost modal one.
+func (this *Layer) AddKeySequenceHandler(sequence KeySequence, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Sequence: sequence, Handler: handler})
+}
+
+// Internal method for adding a binding.  Returns the binding it was given.
+func (this *Layer) addKeyBinding(binding *KeyBinding) *KeyBinding {
+	binding.redraw = this.invalidate
+	if binding.Sequence != nil {
+		this.keySequences = addSequenceBinding(this.keySequences, binding)
+	} else {
+		this.layerKeyBindings[binding.Press] = binding
+	}
+
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), []*KeyBinding{binding}, this.getOtherBindingLevels())
+	}
+	this.invalidate()
+	return binding
+}
+
+// Gets all of the layer's bindings - the single key ones sorted by their
+// keys, then the sequences in the order they were added.
+func (this *Layer) GetBindings() []*KeyBinding {
+	return listBindings(this.layerKeyBindings, this.keySequences)
+}
+
+// Removes one of the layer's bindings, as returned when it was added.
+// Nothing happens if it isn't bound anymore.
+func (this *Layer) RemoveBinding(binding *KeyBinding) {
+	this.keySequences = removeKeyBinding(this.layerKeyBindings, this.keySequences, binding)
+	this.invalidate()
+}
+
+// Internal method for getting the level the layer's own bindings are on.
+func (this *Layer) getBindingLevel() bindingLevel {
+	return bindingLevel{this, 1, this.layerKeyBindings, this.keySequences}
+}
+
+// Internal method for getting the levels around the layer which can have
+// bindings that could clash with its own - the ui (once the layer is pushed
+// onto a screen) and the layer's widgets.
+func (this *Layer) getOtherBindingLevels() []bindingLevel {
+	levels := make([]bindingLevel, 0)
+	if this.screen != nil && this.screen.ui != nil {
+		levels = append(levels, this.screen.ui.getBindingLevel())
+	}
+	return appendWidgetBindingLevels(levels, this.widgets)
+}
+
+// Registers a named action on the layer, triggered by keys given as a key
+// sequence spec (like "ctrl+s", see ParseKeySequence) or "" for no keys yet.
+// Keymaps refer to actions by name, so the keys can be changed later on with
+// BindAction or ApplyKeymap.  The bindings run when nothing underneath
+// consumed the key, like the ones added with AddKeyHandler.
+func (this *Layer) AddAction(name string, keys string, handler EventHandler) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	var err error
+	this.keySequences, err = addAction(this.actions, this.layerKeyBindings, this.keySequences, name, keys, handler, this.invalidate)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
+	}
+	return err
+}
+
+// Replaces the keys bound to one of the layer's actions.  Calling this
+// with no keys leaves the action unbound.
+func (this *Layer) BindAction(name string, keys ...string) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	var err error
+	this.keySequences, err = bindAction(this.actions, this.layerKeyBindings, this.keySequences, name, keys)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
+	}
+	return err
+}
+
+// Rebinds the layer's actions which are in the keymap, along with those of its widgets.
+func (this *Layer) ApplyKeymap(keymap Keymap) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	var err error
+	this.keySequences, err = applyKeymap(this.actions, this.layerKeyBindings, this.keySequences, keymap)
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), getKeymapBindings(this.actions, keymap), this.getOtherBindingLevels())
+	}
+	if err != nil {
+		return err
+	}
+	return applyKeymapToWidgets(this.widgets, keymap)
+}
+
+// Take layer level printable-key (rune) handler function.  These only
+// get used while the layer is the topmost modal one.
+func (this *Layer) AddCharKeyCallback(char rune, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Ch: char}, AdaptEventCallback(callback))
+}
+
+// Take layer level meta-key (termbox.Key) handler function.  These only
+// get used while the layer is the topmost modal one.
+func (this *Layer) AddSpecialKeyCallback(key termbox.Key, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Key: key}, AdaptEventCallback(callback))
+}
+
+// Event handling for modal layers, sending the event to the layer's
+// selected widget.  All calls to layer level handlers get a pointer to the
+// layer in question.  Returns whether or not the event was consumed.
+func (this *Layer) HandleEvents(event Event) bool {
+	var selected Widget
+	for _, w := range flattenWidgets(this.widgets) {
+		if w.IsSelectable() && w.IsSelected() {
+			selected = w
+			break
+		}
+	}
+	return this.dispatch(event, selected)
+}
+
+// Internal method which takes an event through the layer on its way to a
+// widget (which can be nil) - capture bindings, then the widget along with
+// the containers it's inside of, then the other bindings.
+func (this *Layer) dispatch(event Event, target Widget) bool {
+	if runKeyBinding(this.layerKeyBindings, this, event, true) {
+		return true
+	}
+	if dispatchToWidget(this.widgets, event, target) {
+		return true
+	}
+	return runKeyBinding(this.layerKeyBindings, this, event, false)
+}
+
+// A "constructor" function to create new layers.  Modal layers capture the
+// input until they're dismissed, and dimBackground darkens everything drawn
+// underneath the layer so it stands out.
+func CreateLayer(modal, dimBackground bool) *Layer {
+	layer := new(Layer)
+	layer.modal = modal
+	layer.dimBackground = dimBackground
+	layer.layerKeyBindings = make(map[KeyPress]*KeyBinding)
+	return layer
+}
@@ -0,0 +1,246 @@
+package tbuikit
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+// A backend which draws into an in-memory grid of cells instead of a real
+// terminal.  It has a fixed size (until Resize is called), remembers the
+// rune and colors of every cell which was drawn and gets its events from
+// whatever the application (typically a test) injects.
+//
+// It's safe to inspect and inject into from a different goroutine than the
+// one running the ui.
+//
+// These shouldn't be created via new() - use the CreateMemoryBackend() call instead.
+type MemoryBackend struct {
+	lock       sync.Mutex
+	width      int
+	height     int
+	cells      []Cell
+	cursorX    int
+	cursorY    int
+	flushCount int
+	mouse      bool
+	events     chan Event
+}
+
+// Nothing to set up, this is just here to satisfy the interface.
+func (this *MemoryBackend) Init() error {
+	return nil
+}
+
+// Nothing to restore, this is just here to satisfy the interface.
+func (this *MemoryBackend) Close() {}
+
+// Records a cell.  Anything outside the grid is dropped, like a terminal would.
+func (this *MemoryBackend) SetCell(x, y int, ch rune, fg, bg Color) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if x < 0 || y < 0 || x >= this.width || y >= this.height {
+		return
+	}
+	this.cells[y*this.width+x] = Cell{Ch: ch, Fg: fg, Bg: bg}
+}
+
+// Records where the cursor was moved to.
+func (this *MemoryBackend) SetCursor(x, y int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.cursorX = x
+	this.cursorY = y
+}
+
+// Hides the cursor, which means its position becomes -1, -1.
+func (this *MemoryBackend) HideCursor() {
+	this.SetCursor(-1, -1)
+}
+
+// Gets the dimensions of the grid.
+func (this *MemoryBackend) Size() (width, height int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.width, this.height
+}
+
+// Resets every cell to a blank one in the provided colors.
+func (this *MemoryBackend) Clear(fg, bg Color) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for i := range this.cells {
+		this.cells[i] = Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+	return nil
+}
+
+// There's no terminal to push to, so this only counts frames.
+func (this *MemoryBackend) Flush() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.flushCount++
+	return nil
+}
+
+// Waits for the next injected event.
+func (this *MemoryBackend) PollEvent() Event {
+	return <-this.events
+}
+
+// Records whether or not the ui wants mouse events.
+func (this *MemoryBackend) SetMouseEnabled(enabled bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.mouse = enabled
+}
+
+// Checks whether or not the ui asked for mouse events.
+func (this *MemoryBackend) IsMouseEnabled() bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.mouse
+}
+
+// Makes PollEvent return an EVENT_INTERRUPT once it gets through whatever
+// was injected before.
+func (this *MemoryBackend) Interrupt() {
+	this.InjectEvent(Event{Type: EVENT_INTERRUPT})
+}
+
+// Queues up an event for the ui to pick up, as if it came from the terminal.
+// Blocks if there are a lot of events queued up and nothing is reading them.
+func (this *MemoryBackend) InjectEvent(ev Event) {
+	this.events <- ev
+}
+
+// Queues up a meta key (those of type termbox.Key) press.
+func (this *MemoryBackend) InjectKey(key termbox.Key) {
+	this.InjectEvent(CreateKeyEvent(key, 0))
+}
+
+// Queues up a printable key press.
+func (this *MemoryBackend) InjectChar(char rune) {
+	this.InjectEvent(CreateCharEvent(char, 0))
+}
+
+// Queues up a mouse event.
+func (this *MemoryBackend) InjectMouse(action MouseAction, x, y int) {
+	this.InjectEvent(CreateMouseEvent(action, x, y))
+}
+
+// Queues up some pasted text.
+func (this *MemoryBackend) InjectPaste(text string) {
+	this.InjectEvent(CreatePasteEvent(text))
+}
+
+// Changes the size of the grid (wiping it in the process) and queues up the
+// resize event which goes along with it.
+func (this *MemoryBackend) Resize(width, height int) {
+	this.lock.Lock()
+	this.width = width
+	this.height = height
+	this.cells = make([]Cell, width*height)
+	this.lock.Unlock()
+
+	this.InjectEvent(CreateResizeEvent(width, height))
+}
+
+// Gets whatever was last drawn at a position.  Returns an empty cell for
+// positions outside the grid.
+func (this *MemoryBackend) GetCell(x, y int) Cell {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if x < 0 || y < 0 || x >= this.width || y >= this.height {
+		return Cell{}
+	}
+	return this.cells[y*this.width+x]
+}
+
+// Gets the position the cursor was last moved to (-1, -1 if hidden).
+func (this *MemoryBackend) GetCursor() (x, y int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.cursorX, this.cursorY
+}
+
+// Gets how many times the backend was flushed, which is how many
+// frames the ui has drawn.
+func (this *MemoryBackend) GetFlushCount() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.flushCount
+}
+
+// Gets the text drawn inside of a rectangle, one string per row.  Like the
+// widgets, this treats X2 and Y2 as part of the rectangle.  Cells which were
+// never drawn come back as spaces, and the cell after a wide character is
+// left out since the character covers it on a terminal.
+func (this *MemoryBackend) GetRectText(rect *Rectangle) []string {
+	lines := make([]string, 0)
+	for y := rect.Y1; y <= rect.Y2; y++ {
+		var line strings.Builder
+		for x := rect.X1; x <= rect.X2; x++ {
+			ch := this.GetCell(x, y).Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			line.WriteRune(ch)
+			if runewidth.RuneWidth(ch) == 2 {
+				x++
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// Gets the text of the whole grid, one line per row.
+func (this *MemoryBackend) String() string {
+	width, height := this.Size()
+	if width == 0 || height == 0 {
+		return ""
+	}
+	return strings.Join(this.GetRectText(CreateRectangle(0, width-1, 0, height-1)), "\n")
+}
+
+// Fails the test if the text drawn inside of a rectangle isn't exactly the
+// expected lines (one per row, see GetRectText).
+func (this *MemoryBackend) AssertRectText(t TestingT, rect *Rectangle, expected ...string) {
+	t.Helper()
+
+	actual := this.GetRectText(rect)
+	if len(actual) != len(expected) {
+		t.Errorf("rect %v: expected %d lines of text but it has %d:\n%s", *rect, len(expected), len(actual), strings.Join(actual, "\n"))
+		return
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("rect %v, line %d: expected %q but got %q", *rect, i, expected[i], actual[i])
+		}
+	}
+}
+
+// A "constructor" function to create a memory backend of a given size.
+func CreateMemoryBackend(width, height int) *MemoryBackend {
+	backend := new(MemoryBackend)
+	backend.width = width
+	backend.height = height
+	backend.cells = make([]Cell, width*height)
+	backend.cursorX = -1
+	backend.cursorY = -1
+	backend.events = make(chan Event, 64)
+	return backend
+}
@@ -0,0 +1,97 @@
+package tbuikit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+// A TestingT which records failures instead of failing the test, for
+// checking that the assertions fail when they should
+type recordingT struct {
+	errors []string
+}
+
+func (this *recordingT) Helper() {}
+
+func (this *recordingT) Errorf(format string, args ...interface{}) {
+	this.errors = append(this.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMemoryBackendInjectedEventsComeBackInOrder(t *testing.T) {
+	mem := CreateMemoryBackend(10, 5)
+	mem.InjectKey(termbox.KeyEnter)
+	mem.InjectChar('q')
+	mem.InjectMouse(MOUSE_LEFT, 3, 4)
+	mem.InjectPaste("pasted")
+	mem.Interrupt()
+
+	if ev := mem.PollEvent(); !ev.IsKey(termbox.KeyEnter) {
+		t.Errorf("expected enter, got %+v", ev)
+	}
+	if ev := mem.PollEvent(); ev.Type != EVENT_KEY || ev.Ch != 'q' {
+		t.Errorf("expected q, got %+v", ev)
+	}
+	ev := mem.PollEvent()
+	if ev.Type != EVENT_MOUSE || ev.Mouse.Action != MOUSE_LEFT || ev.Mouse.X != 3 || ev.Mouse.Y != 4 {
+		t.Errorf("expected a left click at 3,4, got %+v", ev)
+	}
+	if ev := mem.PollEvent(); ev.Type != EVENT_PASTE || ev.Text != "pasted" {
+		t.Errorf("expected the paste, got %+v", ev)
+	}
+	if ev := mem.PollEvent(); ev.Type != EVENT_INTERRUPT {
+		t.Errorf("expected an interrupt, got %+v", ev)
+	}
+}
+
+func TestMemoryBackendResizeWipesAndQueuesEvent(t *testing.T) {
+	mem := CreateMemoryBackend(10, 5)
+	mem.SetCell(1, 1, 'x', COLOR_RED, COLOR_DEFAULT)
+	mem.Resize(4, 2)
+
+	if width, height := mem.Size(); width != 4 || height != 2 {
+		t.Errorf("expected 4x2, got %dx%d", width, height)
+	}
+	if cell := mem.GetCell(1, 1); cell.Ch != 0 {
+		t.Errorf("expected the grid to be wiped, got %+v", cell)
+	}
+	if ev := mem.PollEvent(); ev.Type != EVENT_RESIZE || ev.Width != 4 || ev.Height != 2 {
+		t.Errorf("expected a resize to 4x2, got %+v", ev)
+	}
+}
+
+func TestMemoryBackendGetRectText(t *testing.T) {
+	mem := CreateMemoryBackend(8, 3)
+	SurfacePrint(mem, 1, 0, COLOR_WHITE, COLOR_DEFAULT, "hello")
+	SurfacePrint(mem, 0, 1, COLOR_WHITE, COLOR_DEFAULT, "a日本b")
+
+	mem.AssertRectText(t, CreateRectangle(1, 5, 0, 0), "hello")
+	mem.AssertRectText(t, CreateRectangle(0, 7, 0, 2), " hello  ", "a日本b  ", "        ")
+
+	expected := " hello  \na日本b  \n        "
+	if mem.String() != expected {
+		t.Errorf("expected %q, got %q", expected, mem.String())
+	}
+
+	if cell := mem.GetCell(20, 20); cell != (Cell{}) {
+		t.Errorf("expected an empty cell outside of the grid, got %+v", cell)
+	}
+}
+
+func TestMemoryBackendAssertRectTextFails(t *testing.T) {
+	mem := CreateMemoryBackend(8, 2)
+	SurfacePrint(mem, 0, 0, COLOR_WHITE, COLOR_DEFAULT, "hello")
+
+	rt := new(recordingT)
+	mem.AssertRectText(rt, CreateRectangle(0, 4, 0, 0), "help!")
+	if len(rt.errors) != 1 {
+		t.Errorf("expected a mismatched line to fail once, got %q", rt.errors)
+	}
+
+	rt = new(recordingT)
+	mem.AssertRectText(rt, CreateRectangle(0, 4, 0, 1), "hello")
+	if len(rt.errors) != 1 {
+		t.Errorf("expected the wrong number of lines to fail once, got %q", rt.errors)
+	}
+}
@@ -0,0 +1,221 @@
+package tbuikit
+
+import (
+	"github.com/mattn/go-runewidth"
+)
+
+// A widget which holds other widgets, optionally inside of a border with a
+// title in it.
+//
+// The children's CalcFunctions work relative to the inside of the panel - 0,0
+// is its top left corner, inside of the border - so they move along with the
+// panel, and anything they draw past its edges gets cut off.  Interior() is
+// the CalcFunction for the whole inside, for filling it or laying it out:
+//
+//	panel := NewPanel("Options", WithLayout(calc))
+//	rows := CreateVBox(panel.Interior)
+//	panel.AddWidget(NewButton("OK", WithLayout(rows.Add(Fixed(3)))))
+//
+// The panel itself can't be selected, but the selectable widgets inside of it
+// are part of the screen's tab order, right where the panel is.  Keys on their
+// way to one of those widgets go through the panel's capture bindings first,
+// and the panel's other bindings get them if the widget doesn't consume them.
+//
+// The title is drawn in the accent style of the panel's theme (as
+// ROLE_PANEL).  Setting a theme on the panel itself with SetTheme changes
+// the theme of the widgets inside of it too, unless they have their own.
+//
+// These shouldn't be created via new() - use the NewPanel() call instead.
+type PanelWidget struct {
+	themeable
+	keyBindings
+
+	title       string
+	drawBorders bool
+	widgets     []Widget
+
+	calcFunction CalcFunction
+	rect         *Rectangle
+}
+
+// Draws the panel's background and border, and then its children on top
+// of it, clipped to its interior.
+func (this *PanelWidget) Draw(canvas *Canvas) {
+	style := this.getStyle(canvas, STATE_NORMAL)
+	canvas.Fill(' ', style.Text.GetFg(), style.Text.GetBg())
+
+	interior := canvas.withTheme(this.theme)
+	if this.drawBorders {
+		this.drawBorderAndTitle(canvas, style)
+		rect := canvas.GetRect()
+		interior = interior.Sub(CreateRectangle(rect.X1+1, rect.X2-1, rect.Y1+1, rect.Y2-1))
+	}
+
+	for _, w := range this.widgets {
+		w.Draw(interior.Sub(w.GetRect()))
+	}
+}
+
+// This draws the border lines around the widget, with the title (if there
+// is one) set into the top line.
+func (this *PanelWidget) drawBorderAndTitle(canvas *Canvas, style WidgetStyle) {
+	rect := canvas.GetRect()
+	borderColor := style.Border.GetFg()
+	bgColor := style.Border.GetBg()
+
+	// Draw corners
+	canvas.SetCell(rect.X1, rect.Y1, 0x250C, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y1, 0x2510, borderColor, bgColor)
+	canvas.SetCell(rect.X1, rect.Y2, 0x2514, borderColor, bgColor)
+	canvas.SetCell(rect.X2, rect.Y2, 0x2518, borderColor, bgColor)
+
+	for i := rect.X1 + 1; i < rect.X2; i++ {
+		canvas.SetCell(i, rect.Y1, 0x2500, borderColor, bgColor)
+		canvas.SetCell(i, rect.Y2, 0x2500, borderColor, bgColor)
+	}
+
+	for i := rect.Y1 + 1; i < rect.Y2; i++ {
+		canvas.SetCell(rect.X1, i, 0x2502, borderColor, bgColor)
+		canvas.SetCell(rect.X2, i, 0x2502, borderColor, bgColor)
+	}
+
+	if this.title == "" {
+		return
+	}
+
+	// The title sits between the corners with a space on each side,
+	// cut short if the panel is too narrow for it
+	room := rect.X2 - rect.X1 - 3
+	if room < 0 {
+		return
+	}
+	title := runewidth.Truncate(" "+this.title+" ", room, "")
+	SurfacePrint(canvas, rect.X1+2, rect.Y1, style.Accent.GetFg(), style.Accent.GetBg(), title)
+}
+
+// Adds a new widget to the panel, positioned relative to the panel's
+// interior.
+func (this *PanelWidget) AddWidget(widget Widget) {
+	this.widgets = append(this.widgets, widget)
+	if this.screen != nil {
+		linkToScreen(widget, this.screen)
+		this.screen.warnAddedWidgetShadowing(widget)
+	}
+	if this.rect != nil {
+		widget.CalculateSize()
+	}
+	this.invalidate()
+}
+
+// Internal method for telling the panel what screen it's on, along with
+// everything inside of it.
+func (this *PanelWidget) setScreen(screen *Screen) {
+	this.screen = screen
+	for _, w := range this.widgets {
+		linkToScreen(w, screen)
+	}
+}
+
+// Gets the widgets directly inside of the panel, in the order they were
+// added.
+func (this *PanelWidget) GetChildren() []Widget {
+	return this.widgets
+}
+
+// Gets the area inside of the panel's border (or all of it, without one),
+// which the children are positioned relative to and clipped to.
+func (this *PanelWidget) GetInteriorRect() *Rectangle {
+	rect := this.GetRect()
+	if this.drawBorders {
+		return CreateRectangle(rect.X1+1, rect.X2-1, rect.Y1+1, rect.Y2-1)
+	}
+	return CreateRectangle(rect.X1, rect.X2, rect.Y1, rect.Y2)
+}
+
+// A CalcFunction for the whole inside of the panel, in the children's
+// coordinates.
+func (this *PanelWidget) Interior() (x1, x2, y1, y2 int) {
+	interior := this.GetInteriorRect()
+	return 0, interior.X2 - interior.X1, 0, interior.Y2 - interior.Y1
+}
+
+// Meant to be called when the terminal dimensions are resized, it calls the callback function and
+// refigures out the sizing and positioning of the rectangle, and then the children's.
+func (this *PanelWidget) CalculateSize() {
+	rect := CreateRectangle(this.calcFunction())
+	this.rect = rect
+	for _, w := range this.widgets {
+		w.CalculateSize()
+	}
+}
+
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *PanelWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *PanelWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
+// The panel itself can't be selected, only the widgets inside of it.
+func (this *PanelWidget) IsSelectable() bool {
+	return false
+}
+
+// The panel itself can't be selected, only the widgets inside of it.
+func (this *PanelWidget) IsSelected() bool {
+	return false
+}
+
+// The panel itself can't be selected.
+// For the moment do nothing, this is just here to satisfy the interface.
+func (this *PanelWidget) Select() {}
+
+// The panel itself can't be selected.
+// For the moment do nothing, this is just here to satisfy the interface.
+func (this *PanelWidget) Unselect() {}
+
+// Keys normally go to the widget inside of the panel, passing through the
+// panel's bindings on the way (see dispatchToWidget), so the only events the
+// panel gets itself are mouse events on its border or on a spot without a
+// child.  It doesn't consume those.
+func (this *PanelWidget) HandleEvents(event Event) bool {
+	return runKeyBinding(this.widgetKeyBindings, this, event, true) ||
+		runKeyBinding(this.widgetKeyBindings, this, event, false)
+}
+
+// Setter for the title shown in the panel's top border.
+func (this *PanelWidget) SetTitle(title string) {
+	this.title = title
+	this.invalidate()
+}
+
+// Sets whether or not the border gets drawn around the widget.
+func (this *PanelWidget) SetDrawBorders(draw bool) {
+	this.drawBorders = draw
+	this.invalidate()
+}
+
+// A "constructor" function to create new panels.  Panels start out with a
+// border (the title only shows up when it's drawn), drawn by their theme and
+// covering the whole screen - the options change that.
+func NewPanel(title string, options ...WidgetOption) *PanelWidget {
+	widget := new(PanelWidget)
+	widget.role = ROLE_PANEL
+	widget.title = title
+	widget.drawBorders = true
+	widget.calcFunction = FullScreen
+	widget.keyBindings = createKeyBindings(widget)
+
+	applyOptions(widget, options)
+	return widget
+}
@@ -2,6 +2,8 @@ package tbuikit
 
 import (
 	"github.com/nsf/termbox-go"
+	"strings"
+	"unicode/utf8"
 )
 
 // A widget meant for password input.  It stores the real value in the buffer but it displays
@@ -10,79 +12,116 @@ import (
 // To control where it is drawn on the screen, it uses a function value which returns the four corners
 // of a rectangle, which represents it's location on the screen.
 //
-// These shouldn't be created via new() - use the CreatePasswordInputWidget() call instead.
+// These shouldn't be created via new() - use the NewPasswordInput() call instead.
 type PasswordInputWidget struct {
-	rect              *Rectangle
-	hasCursor         bool
-	defaultTextColor  termbox.Attribute
-	defaultBgColor    termbox.Attribute
-	selectedBgColor   termbox.Attribute
-	calcFunction      CalcFunction
-	buffer            *TextInputBuffer
-	isSelectable      bool
-	selected          bool
-	widgetKeyBindings map[interface{}]EventCallback
-	defaultHandler    bool
+	themeable
+	keyBindings
+
+	rect           *Rectangle
+	hasCursor      bool
+	calcFunction   CalcFunction
+	buffer         *TextInputBuffer
+	isSelectable   bool
+	selected       bool
+	disabled       bool
+	focusCallback  WidgetCallback
+	blurCallback   WidgetCallback
+	defaultHandler bool
 }
 
 // This is the draw call - it takes a buffer type which meets the text buffer interface
 // and draws the text in it to the screen at the positions defined by its rectangle.
-func (this *PasswordInputWidget) Draw() {
-	if this.rect == nil {
-		this.CalculateSize()
-	}
+func (this *PasswordInputWidget) Draw(canvas *Canvas) {
+	rect := canvas.GetRect()
+	style := this.getStyle(canvas, this.getState())
 
-	this.drawBorderAndBg()
+	this.drawBorderAndBg(canvas, style.Border)
 
-	lines := this.buffer.GetLines(this.rect.Width()-1, this.rect.Height()-1)
+	lines := this.getMaskedLines(rect.Width()-1, rect.Height()-1)
 	linesLen := len(lines)
 	heightMod := 0
+	asts := ""
 	for i := 0; i < linesLen; i++ {
-		astLen := len(lines[i])
-		asts := ""
-		for j := 0; j < astLen; j++ {
-			asts += "*"
-		}
-		TermboxPrintf(this.rect.X1+1, this.rect.Y2-linesLen+heightMod, this.defaultTextColor, termbox.ColorDefault, asts)
+		asts = lines[i]
+		SurfacePrint(canvas, rect.X1+1, rect.Y2-linesLen+heightMod, style.Text.GetFg(), style.Text.GetBg(), asts)
 		heightMod++
 	}
 
 	if this.hasCursor && this.selected {
 		if this.buffer.IsEmpty() {
-			termbox.SetCursor(this.rect.X1+1, this.rect.Y1+(this.rect.Height())-1)
+			canvas.SetCursor(rect.X1+1, rect.Y1+(rect.Height())-1)
 		} else {
-			MoveCursor(this.rect.X1, this.rect.Y2-1, lines[linesLen-1])
+			SurfaceMoveCursor(canvas, rect.X1, rect.Y2-1, asts)
 		}
 	}
 }
 
+// Internal method which masks the buffer's contents, one asterisk per
+// character however wide it is, and splits that into lines of lineLength,
+// keeping the last lineCount of them (when that isn't 0).  The masking has
+// to come first - wrapping the real text between words would give away where
+// the spaces are.
+func (this *PasswordInputWidget) getMaskedLines(lineLength, lineCount int) []string {
+	masked := strings.Repeat("*", utf8.RuneCountInString(this.buffer.GetText()))
+	if lineLength <= 0 {
+		return []string{masked}
+	}
+
+	lines := make([]string, 0)
+	for len(masked) > lineLength {
+		lines = append(lines, masked[:lineLength])
+		masked = masked[lineLength:]
+	}
+	lines = append(lines, masked)
+
+	if lineCount != 0 && len(lines) > lineCount {
+		lines = lines[len(lines)-lineCount:]
+	}
+	return lines
+}
+
 // This draws the border lines around the widget
 //
 // TODO: probably paint BG colors, thought this may need to be
 // in tandem with the normal Draw function as well.
-func (this *PasswordInputWidget) drawBorderAndBg() {
+func (this *PasswordInputWidget) drawBorderAndBg(canvas *Canvas, style Style) {
+	rect := canvas.GetRect()
+	color := style.GetFg()
+	bgColor := style.GetBg()
 
-	var color termbox.Attribute
-	if this.selected {
-		color = this.selectedBgColor | termbox.AttrBold
-	} else {
-		color = this.defaultBgColor
+	// Draw corners
+	canvas.SetCell(rect.X1, rect.Y1, 0x250C, color, bgColor)
+	canvas.SetCell(rect.X2, rect.Y1, 0x2510, color, bgColor)
+	canvas.SetCell(rect.X1, rect.Y2, 0x2514, color, bgColor)
+	canvas.SetCell(rect.X2, rect.Y2, 0x2518, color, bgColor)
+
+	for i := rect.X1 + 1; i < rect.X2; i++ {
+		canvas.SetCell(i, rect.Y1, 0x2500, color, bgColor)
+		canvas.SetCell(i, rect.Y2, 0x2500, color, bgColor)
 	}
 
-	// Draw corners
-	termbox.SetCell(this.rect.X1, this.rect.Y1, 0x250C, color, termbox.ColorDefault)
-	termbox.SetCell(this.rect.X2, this.rect.Y1, 0x2510, color, termbox.ColorDefault)
-	termbox.SetCell(this.rect.X1, this.rect.Y2, 0x2514, color, termbox.ColorDefault)
-	termbox.SetCell(this.rect.X2, this.rect.Y2, 0x2518, color, termbox.ColorDefault)
+	for i := rect.Y1 + 1; i < rect.Y2; i++ {
+		canvas.SetCell(rect.X1, i, 0x2502, color, bgColor)
+		canvas.SetCell(rect.X2, i, 0x2502, color, bgColor)
+	}
+}
 
-	for i := this.rect.X1 + 1; i < this.rect.X2; i++ {
-		termbox.SetCell(i, this.rect.Y1, 0x2500, color, termbox.ColorDefault)
-		termbox.SetCell(i, this.rect.Y2, 0x2500, color, termbox.ColorDefault)
+// Internal method for getting the state the widget gets drawn in.
+func (this *PasswordInputWidget) getState() WidgetState {
+	if this.disabled {
+		return STATE_DISABLED
+	} else if this.selected {
+		return STATE_FOCUSED
 	}
+	return STATE_NORMAL
+}
 
-	for i := this.rect.Y1 + 1; i < this.rect.Y2; i++ {
-		termbox.SetCell(this.rect.X1, i, 0x2502, color, termbox.ColorDefault)
-		termbox.SetCell(this.rect.X2, i, 0x2502, color, termbox.ColorDefault)
+// Internal method for telling the widget what screen it's on, which its
+// buffer gets told too.
+func (this *PasswordInputWidget) setScreen(screen *Screen) {
+	this.screen = screen
+	if this.buffer != nil {
+		this.buffer.setScreen(screen)
 	}
 }
 
@@ -93,9 +132,52 @@ func (this *PasswordInputWidget) CalculateSize() {
 	this.rect = rect
 }
 
-// Check if this widget should be flaggable as selected.
+// Gets the widget's rectangle, figuring it out first if that hasn't been
+// done yet.
+func (this *PasswordInputWidget) GetRect() *Rectangle {
+	if this.rect == nil {
+		this.CalculateSize()
+	}
+	return this.rect
+}
+
+// Replaces the function which works out where the widget goes, see
+// CalcFunction.
+func (this *PasswordInputWidget) SetCalcFunction(calcFunction CalcFunction) {
+	this.calcFunction = calcFunction
+	this.rect = nil
+	this.invalidate()
+}
+
+// Check if this widget should be flaggable as selected.  Disabled widgets
+// never are.
 func (this *PasswordInputWidget) IsSelectable() bool {
-	return this.isSelectable
+	return this.isSelectable && !this.disabled
+}
+
+// Enables or disables the widget.  A disabled widget gets drawn in its
+// theme's STATE_DISABLED style, can't be selected (so it gets unselected
+// if it is) and ignores any keys sent its way.
+func (this *PasswordInputWidget) SetEnabled(enabled bool) {
+	this.disabled = !enabled
+	if this.disabled {
+		this.Unselect()
+	}
+	this.invalidate()
+}
+
+// Check if the widget is enabled.
+func (this *PasswordInputWidget) IsEnabled() bool {
+	return !this.disabled
+}
+
+// Sets whether or not the widget can be selected.  Making it unselectable
+// unselects it if it is.
+func (this *PasswordInputWidget) SetSelectable(selectable bool) {
+	this.isSelectable = selectable
+	if !selectable {
+		this.Unselect()
+	}
 }
 
 // Check if this widget is flagged as selected.  Accessor
@@ -107,24 +189,44 @@ func (this *PasswordInputWidget) IsSelected() bool {
 // Selects this widget - it'd probably make sense
 // to return an error if this widget isn't selectable
 func (this *PasswordInputWidget) Select() {
-	if this.isSelectable {
+	if this.IsSelectable() && !this.selected {
 		this.selected = true
+		if this.focusCallback != nil {
+			this.focusCallback(this)
+		}
+		this.invalidate()
 	}
 }
 
 // Unset selection status
 func (this *PasswordInputWidget) Unselect() {
-	this.selected = false
+	if this.selected {
+		this.selected = false
+		if this.blurCallback != nil {
+			this.blurCallback(this)
+		}
+		this.invalidate()
+	}
+}
+
+// Sets the callback run whenever this widget gets selected.
+func (this *PasswordInputWidget) SetFocusCallback(callback WidgetCallback) {
+	this.focusCallback = callback
+}
+
+// Sets the callback run whenever this widget gets unselected.
+func (this *PasswordInputWidget) SetBlurCallback(callback WidgetCallback) {
+	this.blurCallback = callback
 }
 
 // Take widget level printable-key (rune) handler function
-func (this *PasswordInputWidget) AddCharKeyCallback(char rune, callback EventCallback) {
-	this.widgetKeyBindings[char] = callback
+func (this *PasswordInputWidget) AddCharKeyCallback(char rune, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Ch: char}, AdaptEventCallback(callback))
 }
 
 // Take widget level meta-key (termbox.Key) handler function
-func (this *PasswordInputWidget) AddSpecialKeyCallback(key termbox.Key, callback EventCallback) {
-	this.widgetKeyBindings[key] = callback
+func (this *PasswordInputWidget) AddSpecialKeyCallback(key termbox.Key, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Key: key}, AdaptEventCallback(callback))
 }
 
 // Enable using the default key bindings for the widget.
@@ -132,6 +234,13 @@ func (this *PasswordInputWidget) UseDefaultKeys(use bool) {
 	this.defaultHandler = use
 }
 
+// Sets whether or not the cursor gets shown at the end of the text while
+// the widget is selected.
+func (this *PasswordInputWidget) SetHasCursor(hasCursor bool) {
+	this.hasCursor = hasCursor
+	this.invalidate()
+}
+
 // Get the buffer - if we're able to switch selected items
 // we'll probably want to be able to switch the buffer at the
 // same time.
@@ -139,19 +248,24 @@ func (this *PasswordInputWidget) GetBuffer() *TextInputBuffer {
 	return this.buffer
 }
 
-// If this widget is selected, handle key inputs based on mapped keys
-func (this *PasswordInputWidget) HandleEvents(event interface{}) {
-	if this.widgetKeyBindings[event] != nil {
-		this.widgetKeyBindings[event](this, event)
-	} else {
-		if this.defaultHandler {
-			this.handleDefaultKeys(event)
-		}
+// If this widget is selected, handle key inputs based on mapped keys.  Capture
+// bindings come first, then the default keys (if they're enabled) and then
+// the other bindings.
+func (this *PasswordInputWidget) HandleEvents(event Event) bool {
+	if this.disabled {
+		return false
+	}
+	if runKeyBinding(this.widgetKeyBindings, this, event, true) {
+		return true
+	}
+	if this.defaultHandler && this.handleDefaultKeys(event) {
+		return true
 	}
+	return runKeyBinding(this.widgetKeyBindings, this, event, false)
 }
 
 // This method handles the typical keys passed into a text input widget.
-// Printable characters, backspace/delete and spacebar.
+// Printable characters, backspace/delete and spacebar, plus pasted text.
 //
 // Enter is deliberately left out - different applications will likely
 // have radically different notions about what should be done with the buffers
@@ -160,40 +274,78 @@ func (this *PasswordInputWidget) HandleEvents(event interface{}) {
 // Additionally, this handler function needs to be explicitly enabled - not
 // enabling it means that the application will have to manually define key/char
 // bindings for it's event handler.
-func (this *PasswordInputWidget) handleDefaultKeys(event interface{}) {
+//
+// Returns whether or not the event was one of those keys, anything else
+// is left to bubble up.
+func (this *PasswordInputWidget) handleDefaultKeys(event Event) bool {
+
+	if event.Type == EVENT_PASTE {
+		for _, char := range event.Text {
+			this.GetBuffer().Add(char)
+		}
+		return true
+	}
 
-	key, ok := event.(termbox.Key)
-	if ok {
-		if key == termbox.KeySpace {
+	// Keys pressed along with a modifier are left for bindings
+	if event.Type != EVENT_KEY || event.Mod != 0 {
+		return false
+	}
+
+	if event.Ch == 0 {
+		if event.Key == termbox.KeySpace {
 			this.GetBuffer().Add(' ')
-		} else if key == termbox.KeyBackspace || key == termbox.KeyBackspace2 {
+		} else if event.Key == termbox.KeyBackspace || event.Key == termbox.KeyBackspace2 {
 			this.GetBuffer().Backspace()
+		} else {
+			return false
 		}
-	} else {
-		char, charOk := event.(rune)
-		if charOk {
-			if char != ' ' {
-				this.GetBuffer().Add(char)
-			}
-		}
+	} else if event.Ch != ' ' {
+		this.GetBuffer().Add(event.Ch)
 	}
+	return true
 }
 
-// A "constructor" function to create new widgets.
-func CreatePasswordInputWidget(hasCursor bool, color termbox.Attribute, bg termbox.Attribute, selbg termbox.Attribute,
-	calcFunction CalcFunction, buffer *TextInputBuffer, selectable bool, selected bool) *PasswordInputWidget {
-
+// A "constructor" function to create new password inputs, typing into a buffer.
+// Unlike with CreatePasswordInputWidget, these start out handling typing by themselves
+// (see UseDefaultKeys), as well as selectable, showing the cursor, drawn by
+// their theme and covering the whole screen - the options change that.
+func NewPasswordInput(buffer *TextInputBuffer, options ...WidgetOption) *PasswordInputWidget {
 	widget := new(PasswordInputWidget)
-	widget.hasCursor = hasCursor
-	widget.defaultTextColor = color
-	widget.defaultBgColor = bg
-	widget.selectedBgColor = selbg
-	widget.calcFunction = calcFunction
+	widget.role = ROLE_INPUT
 	widget.buffer = buffer
-	widget.selected = selected
-	widget.isSelectable = selectable
-
-	widget.widgetKeyBindings = make(map[interface{}]EventCallback)
+	widget.hasCursor = true
+	widget.defaultHandler = true
+	widget.calcFunction = FullScreen
+	widget.isSelectable = true
+	widget.keyBindings = createKeyBindings(widget)
 
+	applyOptions(widget, options)
 	return widget
 }
+
+// A "constructor" function to create new widgets.  The bg colors are the
+// ones of the border, when it's selected and when it isn't.
+//
+// Deprecated: the arguments are easy to mix up, use NewPasswordInput instead.
+func CreatePasswordInputWidget(hasCursor bool, color termbox.Attribute, bg termbox.Attribute, selbg termbox.Attribute,
+	calcFunction CalcFunction, buffer *TextInputBuffer, selectable bool, selected bool) *PasswordInputWidget {
+
+	options := []WidgetOption{
+		WithCursor(hasCursor),
+		WithDefaultKeys(false),
+		WithStyle(STATE_NORMAL, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(color), COLOR_DEFAULT),
+			Border: CreateStyle(fromTermbox(bg), COLOR_DEFAULT),
+		}),
+		WithStyle(STATE_FOCUSED, WidgetStyle{
+			Text:   CreateStyle(fromTermbox(color), COLOR_DEFAULT),
+			Border: CreateStyle(fromTermbox(selbg), COLOR_DEFAULT).Bold(),
+		}),
+		WithLayout(calcFunction),
+		Selectable(selectable),
+	}
+	if selected {
+		options = append(options, Selected())
+	}
+	return NewPasswordInput(buffer, options...)
+}
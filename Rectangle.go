@@ -18,6 +18,12 @@ func (this *Rectangle) Height() int {
 	return this.Y2 - this.Y1
 }
 
+// Checks if a point is inside of the rectangle.  Like everywhere else,
+// X2 and Y2 are considered part of the rectangle.
+func (this *Rectangle) Contains(x, y int) bool {
+	return x >= this.X1 && x <= this.X2 && y >= this.Y1 && y <= this.Y2
+}
+
 // Creates a new rectangle object
 func CreateRectangle(x1, x2, y1, y2 int) *Rectangle {
 	rect := new(Rectangle)
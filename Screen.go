@@ -2,23 +2,99 @@ package tbuikit
 
 import (
 	"github.com/nsf/termbox-go"
+	"sort"
 )
 
 // Basically a widget holder at the moment
 type Screen struct {
 	widgets           []Widget
-	screenKeyBindings map[interface{}]EventCallback
+	layers            []*Layer
+	screenKeyBindings map[KeyPress]*KeyBinding
+	keySequences      []*KeyBinding
+	actions           map[string]*namedAction
 	active            bool
+	ui                *UI
+	tabIndices        map[Widget]int
+	defaultFocusKeys  bool
+	theme             *Theme
+
+	// What RenderScreen draws through, instead of the ui's backend
+	backend Backend
+
+	// Lifecycle hooks
+	showCallback       ScreenCallback
+	hideCallback       ScreenCallback
+	resizeCallback     ScreenResizeCallback
+	beforeDrawCallback ScreenCallback
+	afterDrawCallback  ScreenCallback
 }
 
-// Sets this screen to active
+// Sets this screen to active.  Once the screen has been added to a ui,
+// this deactivates every other screen of that ui, the same as UI.SwitchTo.
 func (this *Screen) Activate() {
-	this.active = true
+	if this.ui != nil {
+		this.ui.switchToScreen(this)
+		return
+	}
+	if !this.active {
+		this.active = true
+		this.show()
+	}
+	this.Invalidate()
 }
 
-// Sets this screen to active
+// Sets this screen to inactive
 func (this *Screen) Deactivate() {
-	this.active = false
+	if this.active {
+		this.active = false
+		this.hide()
+	}
+	this.Invalidate()
+}
+
+// Sets the callback run whenever the screen becomes the active one.  This
+// is the place to refresh what the screen shows or reset its focus.
+func (this *Screen) SetShowCallback(callback ScreenCallback) {
+	this.showCallback = callback
+}
+
+// Sets the callback run whenever the screen stops being the active one.
+func (this *Screen) SetHideCallback(callback ScreenCallback) {
+	this.hideCallback = callback
+}
+
+// Sets the callback run whenever the screen gets resized (which includes
+// when it becomes active), along with the new dimensions.  It runs after
+// the widgets have recalculated their sizes.
+func (this *Screen) SetResizeCallback(callback ScreenResizeCallback) {
+	this.resizeCallback = callback
+}
+
+// Sets the callback run right before the screen gets drawn, before it's
+// even cleared.
+func (this *Screen) SetBeforeDrawCallback(callback ScreenCallback) {
+	this.beforeDrawCallback = callback
+}
+
+// Sets the callback run right after the widgets have been drawn, but before
+// the frame is flushed - so anything drawn to the backend from here ends
+// up on top of the widgets.
+func (this *Screen) SetAfterDrawCallback(callback ScreenCallback) {
+	this.afterDrawCallback = callback
+}
+
+// Internal method for running the show hook
+func (this *Screen) show() {
+	if this.showCallback != nil {
+		this.showCallback(this)
+	}
+}
+
+// Internal method for running the hide hook
+func (this *Screen) hide() {
+	if this.hideCallback != nil {
+		this.hideCallback(this)
+	}
 }
 
 // Predicate to see whether or not this screen is active.
@@ -29,18 +105,315 @@ func (this *Screen) IsActive() bool {
 // Adds a new widget to the screen
 func (this *Screen) AddWidget(widget Widget) {
 	this.widgets = append(this.widgets, widget)
+	linkToScreen(widget, this)
+	this.warnAddedWidgetShadowing(widget)
+	this.Invalidate()
+}
+
+// Puts a layer on top of the screen (and any layers already pushed).  When
+// the layer is modal, whatever was selected gets unselected until the layer
+// is removed, and the layer's first selectable widget gets selected if none
+// of them are.
+func (this *Screen) PushLayer(layer *Layer) {
+	if layer.modal {
+		previous, _ := this.GetCurrentSelectedWidget()
+		if previous != nil {
+			previous.Unselect()
+		}
+		layer.previousSelection = previous
+	}
+
+	layer.screen = this
+	this.layers = append(this.layers, layer)
+	for _, w := range layer.widgets {
+		linkToScreen(w, this)
+		w.CalculateSize()
+		this.warnAddedWidgetShadowing(w)
+	}
+
+	if layer.modal {
+		current, _ := this.GetCurrentSelectedWidget()
+		selectable := this.GetSelectableWidgets()
+		if current == nil && len(selectable) > 0 {
+			selectable[0].Select()
+		}
+	}
+	this.Invalidate()
+}
+
+// Removes the topmost layer and returns it, or returns nil
+// if there aren't any layers.
+func (this *Screen) PopLayer() *Layer {
+	count := len(this.layers)
+	if count == 0 {
+		return nil
+	}
+	layer := this.layers[count-1]
+	this.RemoveLayer(layer)
+	return layer
+}
+
+// Removes a layer from the screen.  For modal layers, the widget which was
+// selected before the layer was pushed gets selected again.
+func (this *Screen) RemoveLayer(layer *Layer) {
+	for i, l := range this.layers {
+		if l == layer {
+			this.layers = append(this.layers[:i], this.layers[i+1:]...)
+			layer.screen = nil
+			if layer.modal && layer.previousSelection != nil {
+				layer.previousSelection.Select()
+				layer.previousSelection = nil
+			}
+			this.Invalidate()
+			return
+		}
+	}
+}
+
+// Internal method for getting the topmost modal layer, which is the
+// one getting all of the input.  Returns nil if there's none.
+func (this *Screen) getModalLayer() *Layer {
+	for i := len(this.layers) - 1; i >= 0; i-- {
+		if this.layers[i].modal {
+			return this.layers[i]
+		}
+	}
+	return nil
+}
+
+// Internal method for getting the widgets which can currently be selected -
+// the topmost modal layer's if there is one, the screen's own otherwise.
+func (this *Screen) getFocusWidgets() []Widget {
+	modal := this.getModalLayer()
+	if modal != nil {
+		return flattenWidgets(modal.widgets)
+	}
+	return flattenWidgets(this.widgets)
+}
+
+// Add a keybinding to the screen, which only runs when the selected widget
+// doesn't consume the key itself - so it works as a fallback.
+//
+// This function takes a key press along with its modifiers, so it works for
+// both kinds of keys.
+func (this *Screen) AddKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler})
 }
 
 // Add a keybinding to the screen -- these override widget level keybindings
 // so don't add keys here unless you're sure that the containing widgets won't
 // need them.
 //
-// This function is for metakeys (those of type termbox.Key) - nonprinting keys basically
-func (this *Screen) AddSpecialKeyCallback(event termbox.Key, callback EventCallback) {
-	if len(this.screenKeyBindings) == 0 {
-		this.screenKeyBindings = make(map[interface{}]EventCallback)
+// This function takes a key press along with its modifiers, so it works for
+// both kinds of keys.
+func (this *Screen) AddCaptureKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler, Capture: true})
+}
+
+// Binds a sequence of key presses (like "ctrl+x ctrl+s", or "g g") to a screen
+// level handler, which runs once the whole sequence has been typed.  While
+// it's being typed the keys don't go anywhere else.
+func (this *Screen) AddKeySequenceHandler(sequence KeySequence, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Sequence: sequence, Handler: handler})
+}
+
+// Registers a named action on the screen, triggered by keys given as a key
+// sequence spec (like "ctrl+s", see ParseKeySequence) or "" for no keys yet.
+// Keymaps refer to actions by name, so the keys can be changed later on with
+// BindAction or ApplyKeymap.  The bindings run when nothing underneath
+// consumed the key, like the ones added with AddKeyHandler.
+func (this *Screen) AddAction(name string, keys string, handler EventHandler) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.screenKeyBindings == nil {
+		this.screenKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = addAction(this.actions, this.screenKeyBindings, this.keySequences, name, keys, handler, this.Invalidate)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
+	}
+	return err
+}
+
+// Replaces the keys bound to one of the screen's actions.  Calling this
+// with no keys leaves the action unbound.
+func (this *Screen) BindAction(name string, keys ...string) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.screenKeyBindings == nil {
+		this.screenKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = bindAction(this.actions, this.screenKeyBindings, this.keySequences, name, keys)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
+	}
+	return err
+}
+
+// Rebinds the screen's actions which are in the keymap, along with those of its widgets and layers.
+func (this *Screen) ApplyKeymap(keymap Keymap) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.screenKeyBindings == nil {
+		this.screenKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = applyKeymap(this.actions, this.screenKeyBindings, this.keySequences, keymap)
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), getKeymapBindings(this.actions, keymap), this.getOtherBindingLevels())
+	}
+	if err != nil {
+		return err
+	}
+	for _, layer := range this.layers {
+		err = layer.ApplyKeymap(keymap)
+		if err != nil {
+			return err
+		}
+	}
+	return applyKeymapToWidgets(this.widgets, keymap)
+}
+
+// Internal method for adding a binding, making the map if needed.  Returns
+// the binding it was given.
+func (this *Screen) addKeyBinding(binding *KeyBinding) *KeyBinding {
+	binding.redraw = this.Invalidate
+	if binding.Sequence != nil {
+		this.keySequences = addSequenceBinding(this.keySequences, binding)
+	} else {
+		if len(this.screenKeyBindings) == 0 {
+			this.screenKeyBindings = make(map[KeyPress]*KeyBinding)
+		}
+		this.screenKeyBindings[binding.Press] = binding
+	}
+
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), []*KeyBinding{binding}, this.getOtherBindingLevels())
+	}
+	this.Invalidate()
+	return binding
+}
+
+// Gets all of the screen's bindings - the single key ones sorted by their
+// keys, then the sequences in the order they were added.
+func (this *Screen) GetBindings() []*KeyBinding {
+	return listBindings(this.screenKeyBindings, this.keySequences)
+}
+
+// Removes one of the screen's bindings, as returned when it was added.
+// Nothing happens if it isn't bound anymore.
+func (this *Screen) RemoveBinding(binding *KeyBinding) {
+	this.keySequences = removeKeyBinding(this.screenKeyBindings, this.keySequences, binding)
+	this.Invalidate()
+}
+
+// Internal method for getting the level the screen's own bindings are on.
+func (this *Screen) getBindingLevel() bindingLevel {
+	return bindingLevel{this, 1, this.screenKeyBindings, this.keySequences}
+}
+
+// Internal method for getting the levels around the screen which can have
+// bindings that could clash with its own - the ui and the screen's widgets.
+// Layers are left out, since the screen's bindings aren't used while a
+// modal layer is up.
+func (this *Screen) getOtherBindingLevels() []bindingLevel {
+	levels := make([]bindingLevel, 0)
+	if this.ui != nil {
+		levels = append(levels, this.ui.getBindingLevel())
+	}
+	return appendWidgetBindingLevels(levels, this.widgets)
+}
+
+// Internal method which warns in debug mode about bindings just added to one
+// of the screen's widgets (or one on a layer pushed on it) shadowing, or being
+// shadowed by, bindings around it - the ui, the screen or layer it's on, the
+// containers it's inside of and the widgets inside of it.
+func (this *Screen) warnWidgetShadowing(widget Widget, added []*KeyBinding) {
+	if debugLog == nil || len(added) == 0 {
+		return
+	}
+	depth, others, ok := this.getWidgetOuterBindingLevels(widget)
+	if !ok {
+		return
+	}
+	container, ok := widget.(Container)
+	if ok {
+		others = appendNestedBindingLevels(others, container.GetChildren(), depth+1)
+	}
+	warnShadowing(bindingLevel{widget, depth, nil, nil}, added, others)
+}
+
+// Internal method which warns in debug mode about the bindings of a widget
+// which was just added, and of the widgets inside of it, shadowing or being
+// shadowed by the bindings around them.  Each one only gets checked against
+// the levels outside of it, so nothing gets reported twice.
+func (this *Screen) warnAddedWidgetShadowing(widget Widget) {
+	if debugLog == nil {
+		return
+	}
+	for _, w := range flattenWidgets([]Widget{widget}) {
+		level, ok := getWidgetBindingLevel(w, 0)
+		if !ok {
+			continue
+		}
+		depth, others, ok := this.getWidgetOuterBindingLevels(w)
+		if ok {
+			level.depth = depth
+			warnShadowing(level, listBindings(level.bindings, level.sequences), others)
+		}
 	}
-	this.screenKeyBindings[event] = callback
+}
+
+// Internal method for getting the depth of one of the screen's widgets (or
+// one on a layer pushed on it) along with the levels outside of it - the ui,
+// the screen or layer it's on and the containers it's inside of.  Returns
+// false if the widget isn't on the screen.
+func (this *Screen) getWidgetOuterBindingLevels(widget Widget) (int, []bindingLevel, bool) {
+	levels := make([]bindingLevel, 0)
+	if this.ui != nil {
+		levels = append(levels, this.ui.getBindingLevel())
+	}
+
+	widgets := this.widgets
+	if containsWidget(widgets, widget) {
+		levels = append(levels, this.getBindingLevel())
+	} else {
+		widgets = nil
+		for _, layer := range this.layers {
+			if containsWidget(layer.widgets, widget) {
+				levels = append(levels, layer.getBindingLevel())
+				widgets = layer.widgets
+				break
+			}
+		}
+		if widgets == nil {
+			return 0, nil, false
+		}
+	}
+
+	depth := 2
+	for _, container := range getContainerPath(widgets, widget) {
+		level, ok := getWidgetBindingLevel(container, depth)
+		if ok {
+			levels = append(levels, level)
+		}
+		depth++
+	}
+	return depth, levels, true
+}
+
+// Add a keybinding to the screen -- these override widget level keybindings
+// so don't add keys here unless you're sure that the containing widgets won't
+// need them.
+//
+// This function is for metakeys (those of type termbox.Key) - nonprinting keys basically
+func (this *Screen) AddSpecialKeyCallback(event termbox.Key, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Key: event}, AdaptEventCallback(callback))
 }
 
 // Add a keybinding to the screen -- these override widget level keybindings
@@ -49,11 +422,8 @@ func (this *Screen) AddSpecialKeyCallback(event termbox.Key, callback EventCallb
 //
 // This function is for printing keys - everything that normally prints a
 // character to the screen.  It takes runes as it's argument.
-func (this *Screen) AddCharKeyCallback(char rune, callback EventCallback) {
-	if len(this.screenKeyBindings) == 0 {
-		this.screenKeyBindings = make(map[interface{}]EventCallback)
-	}
-	this.screenKeyBindings[char] = callback
+func (this *Screen) AddCharKeyCallback(char rune, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Ch: char}, AdaptEventCallback(callback))
 }
 
 // To call when the screen resizes - it cycles through each widget
@@ -62,68 +432,556 @@ func (this *Screen) DoResize() {
 	for _, w := range this.widgets {
 		w.CalculateSize()
 	}
+	for _, layer := range this.layers {
+		for _, w := range layer.widgets {
+			w.CalculateSize()
+		}
+	}
+	if this.resizeCallback != nil {
+		width, height := this.getBackend().Size()
+		this.resizeCallback(this, width, height)
+	}
+	this.Invalidate()
+}
+
+// Sets the theme the screen's widgets get drawn with, instead of the ui's.
+// nil goes back to using the ui's.
+func (this *Screen) SetTheme(theme *Theme) {
+	this.theme = theme
+	this.Invalidate()
+}
+
+// Gets the theme set on the screen, nil if it uses the ui's.
+func (this *Screen) GetTheme() *Theme {
+	return this.theme
+}
+
+// Internal method for working out the theme the screen gets drawn with -
+// its own, then the ui's, then the default one.
+func (this *Screen) getTheme() *Theme {
+	if this.theme != nil {
+		return this.theme
+	}
+	if this.ui != nil && this.ui.theme != nil {
+		return this.ui.theme
+	}
+	return defaultTheme
+}
+
+// Marks the screen as dirty, which gets it redrawn if it's the active
+// screen of a running ui.  Only that ui gets woken up - a screen which isn't
+// on a ui yet wakes up every running one (see the Invalidate function).
+func (this *Screen) Invalidate() {
+	if this.ui != nil {
+		this.ui.Invalidate()
+	} else {
+		Invalidate()
+	}
+}
+
+// Internal method for getting the backend the screen draws through - the
+// ui's, or the default one if it isn't on a ui.
+func (this *Screen) getBackend() Backend {
+	if this.backend != nil {
+		return this.backend
+	}
+	if this.ui != nil {
+		return this.ui.GetBackend()
+	}
+	return GetBackend()
 }
 
 // Loop through our widgets and draw them all to the screen.
 func (this *Screen) Draw() {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	if this.beforeDrawCallback != nil {
+		this.beforeDrawCallback(this)
+	}
+
+	// The cursor only shows up if a widget puts it somewhere this frame
+	surface := this.getBackend()
+	surface.Clear(COLOR_DEFAULT, COLOR_DEFAULT)
+	surface.HideCursor()
+	theme := this.getTheme()
 	for _, w := range this.widgets {
-		w.Draw()
+		w.Draw(CreateCanvas(surface, w.GetRect()).withTheme(theme))
+	}
+
+	for _, layer := range this.layers {
+		if layer.dimBackground {
+			dimBackend(surface)
+		}
+		for _, w := range layer.widgets {
+			w.Draw(CreateCanvas(surface, w.GetRect()).withTheme(theme))
+		}
+	}
+
+	if this.afterDrawCallback != nil {
+		this.afterDrawCallback(this)
 	}
-	termbox.Flush()
+	surface.Flush()
 }
 
 // Iterate through the widgets and return the selected one
 // or nil if there isn't one.  Also returns position this widget was
+// in the tab order, or -1 if nothing is selected.
 func (this *Screen) GetCurrentSelectedWidget() (w Widget, ind int) {
 	for i, w := range this.GetSelectableWidgets() {
 		if w.IsSelected() {
 			return w, i
 		}
 	}
-	return nil, 0
+	return nil, -1
 }
 
-// Get all of the selectable widgets held by the screen
+// Get all of the selectable widgets held by the screen, in tab order.
+// While a modal layer is up, only that layer's widgets count.
 func (this *Screen) GetSelectableWidgets() []Widget {
 	toReturn := make([]Widget, 0)
-	for _, w := range this.widgets {
+	for _, w := range this.getFocusWidgets() {
 		if w.IsSelectable() {
 			toReturn = append(toReturn, w)
 		}
 	}
+
+	// Widgets with a tab index come first, lowest index first, and then
+	// everything else in the order it was added
+	sort.SliceStable(toReturn, func(i, j int) bool {
+		iIndex, iOk := this.tabIndices[toReturn[i]]
+		jIndex, jOk := this.tabIndices[toReturn[j]]
+		if iOk && jOk {
+			return iIndex < jIndex
+		}
+		return iOk && !jOk
+	})
 	return toReturn
 }
 
-// Cycles through the selectable widgets
+// Gives a widget an explicit place in the tab order.  Widgets with an index
+// come before the ones without, lowest index first.
+func (this *Screen) SetTabIndex(widget Widget, index int) {
+	if this.tabIndices == nil {
+		this.tabIndices = make(map[Widget]int)
+	}
+	this.tabIndices[widget] = index
+}
+
+// Cycles forward through the selectable widgets, in tab order.  If nothing
+// is selected, the first one gets selected.
 func (this *Screen) SelectNextWidget() {
+	this.cycleSelection(1)
+}
+
+// Cycles backwards through the selectable widgets, in tab order.  If nothing
+// is selected, the last one gets selected.
+func (this *Screen) SelectPreviousWidget() {
+	this.cycleSelection(-1)
+}
+
+// Internal method for moving the selection by some amount of places
+// in the tab order, wrapping around at both ends.
+func (this *Screen) cycleSelection(step int) {
 	selectable := this.GetSelectableWidgets()
-	prevSelected, prevPosition := this.GetCurrentSelectedWidget()
 	count := len(selectable)
-	if count > 1 {
-		if (prevPosition + 1) > (count - 1) {
-			// Start back over at 0
-			prevSelected.Unselect()
-			nextSelected := selectable[0]
-			nextSelected.Select()
+	if count == 0 {
+		return
+	}
+
+	_, position := this.GetCurrentSelectedWidget()
+	if position == -1 && step < 0 {
+		position = 0
+	}
+	next := ((position+step)%count + count) % count
+	this.Focus(selectable[next])
+}
+
+// Moves the selection to the closest selectable widget in a direction,
+// going by where the widgets are on the screen.  Widgets which are more or
+// less in line with the current one are preferred over closer ones which
+// are off to the side.  If nothing is selected, the first widget gets
+// selected, and if there's nothing in that direction nothing happens.  A
+// selected widget which hasn't been laid out yet falls back to cycling.
+func (this *Screen) SelectWidgetInDirection(direction Direction) {
+	current, _ := this.GetCurrentSelectedWidget()
+	if current == nil {
+		this.SelectNextWidget()
+		return
+	}
+
+	// Without a rect there's nothing to measure from, so just cycle
+	from := this.getWidgetRect(current)
+	if from == nil {
+		if direction == UP || direction == LEFT {
+			this.cycleSelection(-1)
 		} else {
-			prevSelected.Unselect()
-			nextSelected := selectable[prevPosition+1]
-			nextSelected.Select()
+			this.cycleSelection(1)
+		}
+		return
+	}
+	fromX := (from.X1 + from.X2) / 2
+	fromY := (from.Y1 + from.Y2) / 2
+
+	var best Widget
+	bestScore := 0
+	for _, w := range this.GetSelectableWidgets() {
+		rect := this.getWidgetRect(w)
+		if w == current || rect == nil {
+			continue
+		}
+
+		// How far the widget is in the direction we're going and
+		// how far off to the side it is
+		dx := (rect.X1+rect.X2)/2 - fromX
+		dy := (rect.Y1+rect.Y2)/2 - fromY
+		var distance, offset int
+		switch direction {
+		case UP:
+			distance, offset = -dy, dx
+		case DOWN:
+			distance, offset = dy, dx
+		case LEFT:
+			distance, offset = -dx, dy
+		case RIGHT:
+			distance, offset = dx, dy
+		}
+		if distance <= 0 {
+			continue
+		}
+		if offset < 0 {
+			offset = -offset
+		}
+
+		score := distance + offset*2
+		if best == nil || score < bestScore {
+			best = w
+			bestScore = score
+		}
+	}
+
+	if best != nil {
+		this.Focus(best)
+	}
+}
+
+// Unselects whatever is currently selected and selects the widget passed in
+// instead.  Nothing happens if the widget can't be selected right now, which
+// is the case when it isn't selectable or it's underneath a modal layer.
+func (this *Screen) Focus(widget Widget) {
+	found := false
+	for _, w := range this.GetSelectableWidgets() {
+		if w == widget {
+			found = true
+		}
+	}
+	if !found {
+		return
+	}
+
+	for _, w := range this.GetSelectableWidgets() {
+		if w != widget && w.IsSelected() {
+			w.Unselect()
+		}
+	}
+	widget.Select()
+}
+
+// Enables using the default focus keys on the screen - Tab selects the next
+// widget and the arrow keys move the selection around spatially.  They only
+// get used when neither the selected widget nor the screen's own bindings
+// consumed the key.
+func (this *Screen) UseDefaultFocusKeys(use bool) {
+	this.defaultFocusKeys = use
+}
+
+// Internal method which handles the default focus keys, returning
+// whether or not the event was one of them.
+func (this *Screen) handleDefaultFocusKeys(event Event) bool {
+	if event.Type != EVENT_KEY || event.Ch != 0 || event.Mod != 0 {
+		return false
+	}
+
+	switch event.Key {
+	case termbox.KeyTab:
+		this.SelectNextWidget()
+	case termbox.KeyArrowUp:
+		this.SelectWidgetInDirection(UP)
+	case termbox.KeyArrowDown:
+		this.SelectWidgetInDirection(DOWN)
+	case termbox.KeyArrowLeft:
+		this.SelectWidgetInDirection(LEFT)
+	case termbox.KeyArrowRight:
+		this.SelectWidgetInDirection(RIGHT)
+	default:
+		return false
+	}
+	return true
+}
+
+// Gets the topmost widget (the last one added, since they're drawn in order)
+// which is under a point, or nil if there's nothing there.  Layers are on top
+// of the screen's own widgets, and nothing underneath a modal layer can be hit.
+func (this *Screen) GetWidgetAt(x, y int) Widget {
+	widget, _, _, _ := this.getWidgetAndLayerAt(x, y)
+	return widget
+}
+
+// Internal method which finds the widget under a point like GetWidgetAt,
+// along with the layer it's on (nil for the screen's own widgets) and where
+// the coordinates the widget works in start, which isn't 0,0 for widgets
+// inside of containers.
+func (this *Screen) getWidgetAndLayerAt(x, y int) (Widget, *Layer, int, int) {
+	for i := len(this.layers) - 1; i >= 0; i-- {
+		widget, originX, originY := getWidgetAt(this.layers[i].widgets, x, y)
+		if widget != nil || this.layers[i].modal {
+			return widget, this.layers[i], originX, originY
+		}
+	}
+	widget, originX, originY := getWidgetAt(this.widgets, x, y)
+	return widget, nil, originX, originY
+}
+
+// Internal method for getting a widget's rectangle in screen coordinates,
+// even when it's inside of a container.  Returns nil if the widget isn't on
+// the screen or its topmost modal layer.
+func (this *Screen) getWidgetRect(widget Widget) *Rectangle {
+	widgets := this.widgets
+	modal := this.getModalLayer()
+	if modal != nil {
+		widgets = modal.widgets
+	}
+
+	var found *Rectangle
+	walkWidgets(widgets, 0, 0, func(w Widget, originX, originY int) {
+		rect := w.GetRect()
+		if w == widget && rect != nil {
+			found = CreateRectangle(rect.X1+originX, rect.X2+originX, rect.Y1+originY, rect.Y2+originY)
+		}
+	})
+	return found
+}
+
+// Mouse event handling, the same as passing a mouse event to HandleEvents.
+func (this *Screen) HandleMouse(event MouseEvent) {
+	this.HandleEvents(Event{Type: EVENT_MOUSE, Mouse: event})
+}
+
+// Event handling.  Mouse events go to whatever is under the pointer (and
+// clicking on a selectable widget selects it first), everything else goes
+// to the selected widget.  On the way there the event goes through the
+// screen's capture bindings, the layer the widget is on and the containers
+// it's inside of, and if nothing consumed it, it comes back through the
+// screen's other bindings and the default focus keys.  While a modal layer
+// is up, the screen's own bindings are skipped entirely.  All calls to
+// screen level handlers get a pointer to the screen in question.
+//
+// Returns whether or not the event was consumed.
+func (this *Screen) HandleEvents(event Event) bool {
+	var target Widget
+	var layer *Layer
+
+	if event.Type == EVENT_MOUSE {
+		var originX, originY int
+		target, layer, originX, originY = this.getWidgetAndLayerAt(event.Mouse.X, event.Mouse.Y)
+		if target == nil {
+			return false
+		}
+
+		// Widgets inside of containers get the position relative to
+		// their container
+		event.Mouse.X -= originX
+		event.Mouse.Y -= originY
+
+		action := event.Mouse.Action
+		pressed := action == MOUSE_LEFT || action == MOUSE_MIDDLE || action == MOUSE_RIGHT
+		if pressed && target.IsSelectable() {
+			this.Focus(target)
+		}
+	} else {
+		layer = this.getModalLayer()
+		target, _ = this.GetCurrentSelectedWidget()
+	}
+
+	// Modal layers get everything
+	if layer != nil && layer.modal {
+		return layer.dispatch(event, target)
+	}
+
+	if runKeyBinding(this.screenKeyBindings, this, event, true) {
+		return true
+	}
+	if layer != nil {
+		if layer.dispatch(event, target) {
+			return true
 		}
+	} else if dispatchToWidget(this.widgets, event, target) {
+		return true
 	}
+	if runKeyBinding(this.screenKeyBindings, this, event, false) {
+		return true
+	}
+	return this.defaultFocusKeys && this.handleDefaultFocusKeys(event)
 }
 
-// Keyboard event handling.  All calls to screen level
-// callbacks get  a pointer to the screen in question.
-func (this *Screen) HandleEvents(event interface{}) {
+// Internal method for getting the levels keys get looked up in, deepest
+// first - the selected widget, the containers it's inside of and then either
+// the modal layer or the screen itself.
+func (this *Screen) getBindingLevels() []bindingLevel {
+	levels := make([]bindingLevel, 0)
+	widgets := this.widgets
+	modal := this.getModalLayer()
+	if modal != nil {
+		widgets = modal.widgets
+	}
+
+	target, _ := this.GetCurrentSelectedWidget()
+	if target != nil {
+		containers := getContainerPath(widgets, target)
+		level, ok := getWidgetBindingLevel(target, 2+len(containers))
+		if ok {
+			levels = append(levels, level)
+		}
+		for i := len(containers) - 1; i >= 0; i-- {
+			level, ok := getWidgetBindingLevel(containers[i], 2+i)
+			if ok {
+				levels = append(levels, level)
+			}
+		}
+	}
+
+	if modal != nil {
+		return append(levels, modal.getBindingLevel())
+	}
+	return append(levels, this.getBindingLevel())
+}
 
-	currentWidget, _ := this.GetCurrentSelectedWidget()
+// Internal function which takes an event to a widget among a slice of them
+// (or inside of the containers among them), which can be nil.  The event
+// goes through the capture bindings of the containers the widget is inside
+// of from the outside in, then the widget, and then the containers' other
+// bindings from the inside out.  Returns whether or not the event was
+// consumed.
+func dispatchToWidget(widgets []Widget, event Event, target Widget) bool {
+	if target == nil {
+		return false
+	}
 
-	// Check for screen level keybindings
-	if this.screenKeyBindings[event] != nil {
-		this.screenKeyBindings[event](this, event)
-	} else if currentWidget != nil {
-		currentWidget.HandleEvents(event)
+	containers := getContainerPath(widgets, target)
+	for _, c := range containers {
+		if runContainerBinding(c, event, true) {
+			return true
+		}
+	}
+	if target.HandleEvents(event) {
+		return true
+	}
+	for i := len(containers) - 1; i >= 0; i-- {
+		if runContainerBinding(containers[i], event, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// Internal function which runs a container's binding for an event, if it
+// can have bindings and there's one for the given phase.
+func runContainerBinding(container Widget, event Event, capture bool) bool {
+	holder, ok := container.(keyBindingHolder)
+	if !ok {
+		return false
+	}
+	return runKeyBinding(holder.getKeyBindings(), container, event, capture)
+}
+
+// Gets the containers a widget is inside of, outermost first, looking
+// through a slice of widgets and the containers among them.  Returns nil
+// when the widget is right in the slice, or not there at all.
+func getContainerPath(widgets []Widget, target Widget) []Widget {
+	for _, w := range widgets {
+		container, ok := w.(Container)
+		if !ok {
+			continue
+		}
+		children := container.GetChildren()
+		for _, child := range children {
+			if child == target {
+				return []Widget{w}
+			}
+		}
+		path := getContainerPath(children, target)
+		if path != nil {
+			return append([]Widget{w}, path...)
+		}
+	}
+	return nil
+}
+
+// Checks whether or not a widget is in a slice of them, or inside of one
+// of the containers among them.
+func containsWidget(widgets []Widget, target Widget) bool {
+	for _, w := range flattenWidgets(widgets) {
+		if w == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the topmost widget of a slice which is under a point, looking inside
+// of containers for the child under it.  Also returns where the coordinates
+// the widget works in start, relative to the ones passed in.
+func getWidgetAt(widgets []Widget, x, y int) (Widget, int, int) {
+	for i := len(widgets) - 1; i >= 0; i-- {
+		rect := widgets[i].GetRect()
+		if rect == nil || !rect.Contains(x, y) {
+			continue
+		}
+
+		container, ok := widgets[i].(Container)
+		if ok {
+			interior := container.GetInteriorRect()
+			if interior.Contains(x, y) {
+				child, originX, originY := getWidgetAt(container.GetChildren(), x-interior.X1, y-interior.Y1)
+				if child != nil {
+					return child, originX + interior.X1, originY + interior.Y1
+				}
+			}
+		}
+		return widgets[i], 0, 0
+	}
+	return nil, 0, 0
+}
+
+// Calls fn for each widget of a slice and everything inside of the
+// containers among them, in order - a container comes right before its
+// children.  fn also gets where the coordinates the widget works in start,
+// with the ones of the slice starting at originX, originY.
+func walkWidgets(widgets []Widget, originX, originY int, fn func(widget Widget, originX, originY int)) {
+	for _, w := range widgets {
+		fn(w, originX, originY)
+		container, ok := w.(Container)
+		if ok {
+			interior := container.GetInteriorRect()
+			walkWidgets(container.GetChildren(), originX+interior.X1, originY+interior.Y1, fn)
+		}
+	}
+}
+
+// Gets every widget of a slice along with everything inside of the
+// containers among them, in the order walkWidgets goes through them.
+func flattenWidgets(widgets []Widget) []Widget {
+	flattened := make([]Widget, 0, len(widgets))
+	walkWidgets(widgets, 0, 0, func(w Widget, originX, originY int) {
+		flattened = append(flattened, w)
+	})
+	return flattened
+}
+
+// Redraws everything in the backend's buffer in DIM_COLOR.
+func dimBackend(backend Backend) {
+	width, height := backend.Size()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cell := backend.GetCell(x, y)
+			backend.SetCell(x, y, cell.Ch, DIM_COLOR, COLOR_DEFAULT)
+		}
 	}
 }
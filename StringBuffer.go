@@ -1,15 +1,38 @@
 package tbuikit
 
+import (
+	"sync"
+)
+
 // This type wraps a string slice to be used
 // to display strings on the screen.
 // Has a maximum size and will truncate old strings once this is reached
+//
+// It's safe to add to the buffer from any goroutine while the main loop
+// is drawing it.
 type StringBuffer struct {
-	holder   []string
-	capacity int
+	screenLink
+
+	lock       sync.Mutex
+	holder     []string
+	capacity   int
+	wrapIndent int
+}
+
+// Internal method for telling the buffer what screen the widget showing it
+// is on, so adding to it only redraws that screen's ui.
+func (this *StringBuffer) setScreen(screen *Screen) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.screen = screen
 }
 
 // Call this to setup the slice when creating one of these
 func (this *StringBuffer) Prepare(capacity int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = make([]string, 0)
 	this.capacity = capacity
 }
@@ -17,28 +40,68 @@ func (this *StringBuffer) Prepare(capacity int) {
 // Adds a new element to the end of the stack, and will clip
 // anything over capacity off the bottom.
 func (this *StringBuffer) Add(str string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = append(this.holder, str)
 	if len(this.holder) > this.capacity {
 		this.truncateOld()
 	}
+	this.invalidate()
+}
+
+// Sets how many spaces the lines after the first one of a long entry get
+// indented by when it's wrapped, see WrapText.
+func (this *StringBuffer) SetWrapIndent(indent int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.wrapIndent = indent
+	this.invalidate()
 }
 
 // Clear the buffer's contents.
 func (this *StringBuffer) Clear() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.holder = make([]string, 0)
+	this.invalidate()
 }
 
 // Gets the lines out of the buffer.  This will split any lines that are too long
-// into two (or as many as it takes until they are shorter than line length) lines
-// and then returns the last <lineCount> number of lines.
+// into two (or as many as it takes until they are shorter than line length) lines,
+// breaking between words and at newlines (see WrapText), and then returns the
+// last <lineCount> number of lines.
 func (this *StringBuffer) GetContents(lineLength, lineCount int) []string {
+	lines, _ := this.GetScrolledContents(lineLength, lineCount, 0)
+	return lines
+}
+
+// Same as GetContents, but skips the last <scrollBack> lines in order to look
+// further back into the buffer.  The scrollback gets clamped so it can't go past
+// the oldest line, and the value which was actually used is returned along with
+// the lines.
+func (this *StringBuffer) GetScrolledContents(lineLength, lineCount, scrollBack int) ([]string, int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	colStringCount := len(this.holder)
 	if lineLength == 0 && colStringCount == 0 {
-		return nil
+		return nil, 0
+	}
+	if lineCount < 0 {
+		lineCount = 0
 	}
+	if scrollBack < 0 {
+		scrollBack = 0
+	}
+
+	// Every message is at least one line, so this is enough messages to
+	// fill the window even when scrolled back.
 	cutOff := 0
-	if lineCount < colStringCount {
-		cutOff = colStringCount - lineCount
+	if lineCount+scrollBack < colStringCount {
+		cutOff = colStringCount - lineCount - scrollBack
 	}
 
 	// Get the "messages" - which can be longer than a line
@@ -47,26 +110,31 @@ func (this *StringBuffer) GetContents(lineLength, lineCount int) []string {
 	// Add everything, splitting any lines that are too long into smaller lines
 	// We'll rechop after
 	for _, str := range unsplitLines {
-		if len(str) <= lineLength {
-			splitLines = append(splitLines, str)
-		} else {
-			newlySplitLines := SplitBufferLines(str, lineLength)
-			for _, newLine := range newlySplitLines {
-				splitLines = append(splitLines, newLine)
-			}
+		newlySplitLines := WrapText(str, lineLength, this.wrapIndent)
+		for _, newLine := range newlySplitLines {
+			splitLines = append(splitLines, newLine)
 		}
 	}
 
-	// now we need to make sure we haven't got more lines than lineCount
-	if len(splitLines) > lineCount {
-		splitLines = splitLines[(len(splitLines) - lineCount):]
+	// Now chop the window we want out of the end, making sure we don't
+	// scroll back further than there are lines
+	if cutOff == 0 && scrollBack > len(splitLines)-lineCount {
+		scrollBack = len(splitLines) - lineCount
+		if scrollBack < 0 {
+			scrollBack = 0
+		}
+	}
+	end := len(splitLines) - scrollBack
+	start := end - lineCount
+	if start < 0 {
+		start = 0
 	}
 
-	return splitLines
-
+	return splitLines[start:end], scrollBack
 }
 
-// Clears oldest colorized strings to get back to capacity
+// Clears oldest colorized strings to get back to capacity.
+// The lock has to be held when calling this.
 func (this *StringBuffer) truncateOld() {
 	length := len(this.holder)
 	cutOff := length - this.capacity
@@ -0,0 +1,53 @@
+package tbuikit
+
+// The colors and attributes (bold, underline, reverse) something gets drawn
+// in.  The attributes only apply to the foreground.
+type Style struct {
+	Fg    Color
+	Bg    Color
+	Attrs Attr
+}
+
+// Gets the foreground color along with the attributes, ready to hand to
+// SetCell.
+func (this Style) GetFg() Color {
+	return this.Fg | Color(this.Attrs)
+}
+
+// Gets the background color.
+func (this Style) GetBg() Color {
+	return this.Bg
+}
+
+// Returns a copy of the style with bold text.
+func (this Style) Bold() Style {
+	this.Attrs |= ATTR_BOLD
+	return this
+}
+
+// Returns a copy of the style with underlined text.
+func (this Style) Underline() Style {
+	this.Attrs |= ATTR_UNDERLINE
+	return this
+}
+
+// Returns a copy of the style with the colors swapped around.
+func (this Style) Reverse() Style {
+	this.Attrs |= ATTR_REVERSE
+	return this
+}
+
+// A "constructor" function to create a style out of a foreground and
+// background color.
+func CreateStyle(fg, bg Color) Style {
+	return Style{Fg: fg, Bg: bg}
+}
+
+// The styles a widget gets drawn with in one state - one for its text, one
+// for its border and one for whatever it makes stand out (like a panel's
+// title, or the keys in a footer).
+type WidgetStyle struct {
+	Text   Style
+	Border Style
+	Accent Style
+}
@@ -0,0 +1,150 @@
+package tbuikit
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// The default backend, which is a thin wrapper around termbox-go.
+type TermboxBackend struct {
+	altInput bool
+}
+
+// Initializes termbox - must be done before anything gets drawn.
+func (this *TermboxBackend) Init() error {
+	return termbox.Init()
+}
+
+// Shuts termbox down and restores the terminal.
+func (this *TermboxBackend) Close() {
+	termbox.Close()
+}
+
+// Sets a single cell in termbox's back buffer.
+func (this *TermboxBackend) SetCell(x, y int, ch rune, fg, bg Color) {
+	termbox.SetCell(x, y, ch, termbox.Attribute(fg), termbox.Attribute(bg))
+}
+
+// Moves the terminal's cursor.
+func (this *TermboxBackend) SetCursor(x, y int) {
+	termbox.SetCursor(x, y)
+}
+
+// Hides the terminal's cursor.
+func (this *TermboxBackend) HideCursor() {
+	termbox.HideCursor()
+}
+
+// Gets the terminal's dimensions.
+func (this *TermboxBackend) Size() (width, height int) {
+	return termbox.Size()
+}
+
+// Clears termbox's back buffer using the provided colors.
+func (this *TermboxBackend) Clear(fg, bg Color) error {
+	return termbox.Clear(termbox.Attribute(fg), termbox.Attribute(bg))
+}
+
+// Reads a cell back from termbox's back buffer.
+func (this *TermboxBackend) GetCell(x, y int) Cell {
+	width, height := termbox.Size()
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return Cell{}
+	}
+	cell := termbox.GetCell(x, y)
+	return Cell{Ch: cell.Ch, Fg: fromTermbox(cell.Fg), Bg: fromTermbox(cell.Bg)}
+}
+
+// Pushes the back buffer out to the terminal.
+func (this *TermboxBackend) Flush() error {
+	return termbox.Flush()
+}
+
+// Waits for the next termbox event.
+func (this *TermboxBackend) PollEvent() Event {
+	return convertTermboxEvent(termbox.PollEvent())
+}
+
+// Makes a pending PollEvent return.
+func (this *TermboxBackend) Interrupt() {
+	termbox.Interrupt()
+}
+
+// Switches termbox's input mode to report mouse events, or back to keys only.
+// Keys come in Esc mode unless SetAltInput asked for Alt mode.
+func (this *TermboxBackend) SetMouseEnabled(enabled bool) {
+	mode := termbox.InputEsc
+	if this.altInput {
+		mode = termbox.InputAlt
+	}
+	if enabled {
+		mode |= termbox.InputMouse
+	}
+	termbox.SetInputMode(mode)
+}
+
+// Turns termbox's Alt input mode on or off - it's off unless this is called
+// with true.  In Alt mode Esc followed by a key comes through as that key
+// with MOD_ALT set, so "alt+..." bindings work, but a lone Esc never comes
+// through as KeyEsc at all - it gets held and merged into the next key.
+// Anything bound to Esc (including closing the dialogs and the help
+// overlay) stops working, so only turn this on when Esc isn't needed.  This
+// has to be called before the ui is started.
+func (this *TermboxBackend) SetAltInput(enabled bool) {
+	this.altInput = enabled
+}
+
+// A "constructor" function to create the termbox backend.
+func CreateTermboxBackend() *TermboxBackend {
+	return new(TermboxBackend)
+}
+
+// Turns a termbox color (and attributes) into one of ours.  Our colors and
+// attributes are numbered the same way as termbox's, so this is only a
+// conversion - it's here for the older functions (TermboxPrint and the
+// Create* widget constructors) which still take termbox colors.
+func fromTermbox(attr termbox.Attribute) Color {
+	return Color(attr)
+}
+
+// Which termbox mouse keys map to which mouse actions
+var mouseActions = map[termbox.Key]MouseAction{
+	termbox.MouseLeft:      MOUSE_LEFT,
+	termbox.MouseMiddle:    MOUSE_MIDDLE,
+	termbox.MouseRight:     MOUSE_RIGHT,
+	termbox.MouseRelease:   MOUSE_RELEASE,
+	termbox.MouseWheelUp:   MOUSE_WHEEL_UP,
+	termbox.MouseWheelDown: MOUSE_WHEEL_DOWN,
+}
+
+// Turns a termbox event into one of ours.  Anything we don't deal with
+// (errors, raw events) becomes an EVENT_NONE.
+func convertTermboxEvent(ev termbox.Event) Event {
+	var mod Modifier
+	if ev.Mod&termbox.ModAlt != 0 {
+		mod |= MOD_ALT
+	}
+	if ev.Mod&termbox.ModMotion != 0 {
+		mod |= MOD_MOTION
+	}
+
+	switch ev.Type {
+	case termbox.EventKey:
+		if ev.Ch != 0 {
+			return CreateCharEvent(ev.Ch, mod)
+		}
+		return CreateKeyEvent(ev.Key, mod)
+	case termbox.EventMouse:
+		action, ok := mouseActions[ev.Key]
+		if !ok {
+			return Event{}
+		}
+		event := CreateMouseEvent(action, ev.MouseX, ev.MouseY)
+		event.Mod = mod
+		return event
+	case termbox.EventResize:
+		return CreateResizeEvent(ev.Width, ev.Height)
+	case termbox.EventInterrupt:
+		return Event{Type: EVENT_INTERRUPT}
+	}
+	return Event{}
+}
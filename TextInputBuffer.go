@@ -1,45 +1,103 @@
 package tbuikit
 
+import (
+	"sync"
+)
+
 // This buffer represents the storage for any field a user can type text into
+//
+// It's safe to use from any goroutine while the main loop is drawing it.
 type TextInputBuffer struct {
+	screenLink
+
+	lock       sync.Mutex
 	charHolder []rune
 	length     int
 }
 
+// Internal method for telling the buffer what screen the widget showing it
+// is on, so adding to it only redraws that screen's ui.
+func (this *TextInputBuffer) setScreen(screen *Screen) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.screen = screen
+}
+
 // Adds a new element to the end of the stack (just a method form of append)
 func (this *TextInputBuffer) Add(char rune) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	// 0 is unlimited length
 	if this.length == 0 {
 		this.charHolder = append(this.charHolder, char)
 	} else if this.length > 1 && len(this.charHolder) < this.length {
 		this.charHolder = append(this.charHolder, char)
 	}
+	this.invalidate()
 }
 
 // Removes the last element from the buffer
 func (this *TextInputBuffer) Backspace() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	length := len(this.charHolder)
 	if length > 1 {
 		this.charHolder = this.charHolder[0 : length-1]
 	} else {
 		this.charHolder = make([]rune, 0)
 	}
+	this.invalidate()
 }
 
 // Wraps the call to toString and then clear,
 // which is what the enter key should do
 func (this *TextInputBuffer) ReturnAndClear() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	contents := string(this.charHolder)
-	this.Clear()
+	this.charHolder = make([]rune, 0)
+	this.invalidate()
 	return contents
 }
 
+// Replaces the buffer's contents, cutting it down to the buffer's
+// length if it has one
+func (this *TextInputBuffer) SetText(text string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.charHolder = []rune(text)
+	if this.length != 0 && len(this.charHolder) > this.length {
+		this.charHolder = this.charHolder[:this.length]
+	}
+	this.invalidate()
+}
+
+// Gets the buffer's contents without clearing it
+func (this *TextInputBuffer) GetText() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return string(this.charHolder)
+}
+
 // Clears the buffer
 func (this *TextInputBuffer) Clear() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.charHolder = make([]rune, 0)
+	this.invalidate()
 }
 
 func (this *TextInputBuffer) SetLength(length int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	this.length = length
 }
 
@@ -52,14 +110,17 @@ func (this *TextInputBuffer) SetLength(length int) {
 // Line count can optionally be used to return only the last n lines,
 // if a maximum number of lines is a concern.
 func (this *TextInputBuffer) GetLines(lineLength, lineCount int) []string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	var lines []string
 	stringified := string(this.charHolder)
 
-	if lineLength != 0 && len(stringified) > lineLength {
-		lines = SplitBufferLines(stringified, lineLength)
-		if lineCount != 0 {
+	if lineLength != 0 {
+		lines = WrapText(stringified, lineLength, 0)
+		if lineCount != 0 && len(lines) > lineCount {
 			totalLen := len(lines)
-			lines = lines[totalLen-lineCount-1:]
+			lines = lines[totalLen-lineCount:]
 		}
 	} else {
 		lines = make([]string, 1)
@@ -70,6 +131,9 @@ func (this *TextInputBuffer) GetLines(lineLength, lineCount int) []string {
 
 // Checks if this buffer is empty
 func (this *TextInputBuffer) IsEmpty() bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	if len(this.charHolder) == 0 {
 		return true
 	}
@@ -0,0 +1,139 @@
+package tbuikit
+
+// A set of styles for every kind of widget (its role) in every state it can
+// be drawn in.  Themes can be set on the ui, overridden for a screen with
+// Screen.SetTheme and for a single widget with its own SetTheme, and a
+// widget's own SetStyle beats them all.  A few ready-made themes come with
+// the library, see CreateDefaultTheme.
+//
+// When a theme has no style for a role and state, it falls back on the
+// ROLE_DEFAULT style for the state, then the role's normal style and then
+// the ROLE_DEFAULT normal one - the state wins over the role, so a widget
+// never looks enabled when it isn't just because its role has a normal
+// style.
+//
+// Besides the widget roles there's ROLE_DIALOG, for the frame (and title) of
+// the dialogs and the help overlay, and ROLE_HEADING, for headings like the
+// help overlay's groups.  Any widget can be given those with SetRole.
+//
+// These shouldn't be created via new() - use the CreateTheme() call instead.
+type Theme struct {
+	name   string
+	styles map[WidgetRole]map[WidgetState]WidgetStyle
+}
+
+// Gets the theme's name
+func (this *Theme) GetName() string {
+	return this.name
+}
+
+// Sets the style widgets of a role get drawn with in a state.  Returns the
+// theme, so these can be chained.
+//
+// This redraws every running ui, not just one - a theme doesn't know where
+// it's used, and the same one can be shared by several uis (the default
+// theme is used by every ui which doesn't have one).
+func (this *Theme) SetStyle(role WidgetRole, state WidgetState, style WidgetStyle) *Theme {
+	if this.styles[role] == nil {
+		this.styles[role] = make(map[WidgetState]WidgetStyle)
+	}
+	this.styles[role][state] = style
+	Invalidate()
+	return this
+}
+
+// Gets the style widgets of a role get drawn with in a state.
+func (this *Theme) GetStyle(role WidgetRole, state WidgetState) WidgetStyle {
+	for _, s := range []WidgetState{state, STATE_NORMAL} {
+		for _, r := range []WidgetRole{role, ROLE_DEFAULT} {
+			style, ok := this.styles[r][s]
+			if ok {
+				return style
+			}
+		}
+	}
+	return WidgetStyle{}
+}
+
+// Makes a copy of the theme under a new name, to tweak a bundled theme
+// without changing it for everyone else.
+func (this *Theme) Copy(name string) *Theme {
+	theme := CreateTheme(name)
+	for role, states := range this.styles {
+		for state, style := range states {
+			theme.SetStyle(role, state, style)
+		}
+	}
+	return theme
+}
+
+// A "constructor" function to create a new, empty theme.
+func CreateTheme(name string) *Theme {
+	theme := new(Theme)
+	theme.name = name
+	theme.styles = make(map[WidgetRole]map[WidgetState]WidgetStyle)
+	return theme
+}
+
+// The part of a widget which works out what it gets drawn with.  The
+// widgets embed it, which gives them SetTheme, SetStyle and ResetStyles.
+// It carries the link to the widget's screen too, so changing a style
+// redraws the right ui.
+type themeable struct {
+	screenLink
+
+	role   WidgetRole
+	theme  *Theme
+	styles map[WidgetState]WidgetStyle
+}
+
+// Sets the theme the widget gets drawn with, instead of the one of the
+// screen or ui it's on.  nil goes back to using theirs.
+func (this *themeable) SetTheme(theme *Theme) {
+	this.theme = theme
+	this.invalidate()
+}
+
+// Sets the style the widget gets drawn with in a state, whatever the
+// theme says.
+func (this *themeable) SetStyle(state WidgetState, style WidgetStyle) {
+	if this.styles == nil {
+		this.styles = make(map[WidgetState]WidgetStyle)
+	}
+	this.styles[state] = style
+	this.invalidate()
+}
+
+// Drops the styles set with SetStyle (including the colors the widget was
+// created with), so the widget gets drawn entirely by its theme.
+func (this *themeable) ResetStyles() {
+	this.styles = nil
+	this.invalidate()
+}
+
+// Gets the role the widget's theme styles it as
+func (this *themeable) GetRole() WidgetRole {
+	return this.role
+}
+
+// Sets the role the widget's theme styles it as, to have it drawn like some
+// other kind of widget (a label styled as a ROLE_HEADING, for example).
+func (this *themeable) SetRole(role WidgetRole) {
+	this.role = role
+	this.invalidate()
+}
+
+// Internal method which works out the style to draw a widget with in a
+// state - its own style for the state if it has one, then its own theme,
+// then the theme of whatever it's drawn in (which the canvas carries).
+func (this *themeable) getStyle(canvas *Canvas, state WidgetState) WidgetStyle {
+	style, ok := this.styles[state]
+	if ok {
+		return style
+	}
+	theme := this.theme
+	if theme == nil {
+		theme = canvas.GetTheme()
+	}
+	return theme.GetStyle(this.role, state)
+}
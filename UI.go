@@ -1,143 +1,816 @@
 package tbuikit
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/nsf/termbox-go"
+	"sync"
 	"time"
 )
 
+// Returned by UI.Run when there's no screen to show
+var ErrNoActiveScreen = errors.New("tbuikit: no active screen")
+
+// Returned when switching to a screen name which was never registered
+var ErrUnknownScreen = errors.New("tbuikit: unknown screen")
+
+// Returned by UI.Pop when there's no screen to go back to
+var ErrNoPreviousScreen = errors.New("tbuikit: no previous screen")
+
 // Represents the top level of a TermboxUIKit UI.  It manages
 // screens, handles the global level keyboard (or resize) events,
 // and handles shutting the entire UI down.
 type UI struct {
 	screenHolder      []*Screen
-	globalKeyBindings map[interface{}]EventCallback
+	namedScreens      map[string]*Screen
+	navigationStack   []*Screen
+	globalKeyBindings map[KeyPress]*KeyBinding
+	keySequences      []*KeyBinding
+	actions           map[string]*namedAction
 	uiShutdownChan    chan bool
-	redrawDelay       time.Duration
+	frameInterval     time.Duration
+	mouseDisabled     bool
+	theme             *Theme
+	backend           Backend
+
+	// The keys typed so far of a key sequence, and the timer which gives
+	// up on it.  The generation changes every time the pending keys do,
+	// so a timer which fires late knows it's out of date.
+	pendingKeys         []Event
+	pendingGeneration   int
+	pendingTimer        *time.Timer
+	sequenceTimeout     time.Duration
+	pendingKeysCallback KeySequenceCallback
+
+	// For the help overlay
+	actionDescriptions map[string]actionDescription
+	helpKey            *KeyPress
+
+	// Work queued up from other goroutines, waiting to be run on the
+	// main loop.  postChan is only used to wake the loop up.  The lock
+	// also guards creating the channels, which are made lazily.
+	lock       sync.Mutex
+	postQueue  []func()
+	postChan   chan bool
+	redrawChan chan bool
 }
 
-func (this *UI) Start(quitChan chan bool) {
+// Runs the ui until either Shutdown is called or ctx is cancelled, blocking
+// in the meantime.  The backend is initialized on the way in and closed on
+// the way out (restoring the terminal), even if a callback panics.
+//
+// Returns ErrNoActiveScreen if there's nothing to show, or whatever error
+// the backend failed to initialize with.  Shutting down normally or through
+// ctx both return nil.
+func (this *UI) Run(ctx context.Context) error {
 	if this.getActiveScreen() == nil {
-		panic("No active screen")
+		return ErrNoActiveScreen
+	}
+
+	backend := this.GetBackend()
+	err := backend.Init()
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+	backend.SetMouseEnabled(!this.mouseDisabled)
+
+	// A Shutdown from before the ui was running doesn't count
+	this.lock.Lock()
+	shutdownChan := this.getShutdownChan()
+	this.lock.Unlock()
+	select {
+	case <-shutdownChan:
+	default:
+	}
+
+	setRunning(this, true)
+	defer setRunning(this, false)
+	this.mainLoop(ctx, backend)
+	return nil
+}
+
+// Sets the backend this ui draws through and reads events from, instead of
+// the default one (see SetBackend).  Giving every ui its own lets more than
+// one of them run at the same time, in tests for example.  This has to be
+// done before the ui is started.
+func (this *UI) SetBackend(backend Backend) {
+	this.backend = backend
+}
+
+// Gets the backend the ui draws through - its own, or the default one.
+func (this *UI) GetBackend() Backend {
+	if this.backend != nil {
+		return this.backend
+	}
+	return GetBackend()
+}
+
+// Gets the dimensions of the ui's backend.
+func (this *UI) GetSize() (width, height int) {
+	return this.GetBackend().Size()
+}
+
+// A CalcFunction covering the whole of the ui's backend - the same as the
+// FullScreen function, but for a ui with its own backend.
+func (this *UI) FullScreen() (x1, x2, y1, y2 int) {
+	w, h := this.GetSize()
+	return 0, w - 1, 0, h - 1
+}
+
+// Marks the ui as dirty so its active screen gets redrawn the next time the
+// main loop gets a chance to.  Any number of these between two frames
+// collapse into a single redraw, and it never blocks, so it can be called
+// from any goroutine.
+func (this *UI) Invalidate() {
+	this.lock.Lock()
+	redrawChan := this.getRedrawChan()
+	this.lock.Unlock()
+
+	select {
+	case redrawChan <- true:
+	default:
 	}
+}
 
-	// Make sure to initialize the channel
-	this.uiShutdownChan = make(chan bool)
+// Starts the ui and blocks until it gets shut down, at which point true is
+// sent on quitChan (if it isn't nil).  This predates Run and panics instead
+// of returning errors.
+func (this *UI) Start(quitChan chan bool) {
+	err := this.Run(context.Background())
+	if err != nil {
+		panic(err)
+	}
 
-	this.mainLoop(quitChan)
+	// Send the quit signal back to the ui caller
+	if quitChan != nil {
+		quitChan <- true
+	}
 }
 
-// Sends the shutdown signal, breaking the main loop
+// Sends the shutdown signal, breaking the main loop.  This never blocks and
+// can be called from anywhere, including the ui's own callbacks.  Calling it
+// while the ui isn't running does nothing - the next Run isn't affected.
 func (this *UI) Shutdown() {
-	this.uiShutdownChan <- true
+	this.lock.Lock()
+	shutdownChan := this.getShutdownChan()
+	this.lock.Unlock()
+
+	select {
+	case shutdownChan <- true:
+	default:
+	}
+}
+
+// Queues a function up to be run on the main loop's goroutine, in between
+// handling events and drawing.  This is how other goroutines (a network
+// reader, a timer...) should touch widgets and screens, since those aren't
+// safe to use concurrently with the main loop.  The ui is redrawn after the
+// function runs.
+//
+// Post never blocks, and can be called before the ui is started - the
+// function will run once the main loop is up.
+func (this *UI) Post(fn func()) {
+	this.lock.Lock()
+	this.postQueue = append(this.postQueue, fn)
+	notify := this.getPostChan()
+	this.lock.Unlock()
+
+	select {
+	case notify <- true:
+	default:
+	}
+}
+
+// Queues up an event to be handled on the main loop as if it came from the
+// backend.  This is mostly meant for EVENT_CUSTOM events (see CreateCustomEvent),
+// which let other goroutines tell the widgets something happened without
+// touching them directly.
+func (this *UI) PostEvent(event Event) {
+	this.Post(func() {
+		this.HandleEvent(event)
+	})
+}
+
+// Same as Post, but waits until the function has been run.  This must not
+// be called from the main loop itself (so not from within a key callback),
+// since it would end up waiting on itself forever.
+func (this *UI) Invoke(fn func()) {
+	done := make(chan bool)
+	this.Post(func() {
+		defer close(done)
+		fn()
+	})
+	<-done
 }
 
-// Adds a screen to the collection
+// Internal method for getting the channel used to wake the main loop up when
+// work gets posted.  It's created lazily since a UI doesn't have a constructor,
+// so the lock has to be held when calling this.
+func (this *UI) getPostChan() chan bool {
+	if this.postChan == nil {
+		this.postChan = make(chan bool, 1)
+	}
+	return this.postChan
+}
+
+// Internal method for getting the shutdown channel, made lazily in the
+// same way as the post channel.  The lock has to be held when calling this.
+func (this *UI) getShutdownChan() chan bool {
+	if this.uiShutdownChan == nil {
+		this.uiShutdownChan = make(chan bool, 1)
+	}
+	return this.uiShutdownChan
+}
+
+// Internal method for getting the channel used to wake the main loop up when
+// the ui needs redrawing, made lazily in the same way as the post channel.
+// The lock has to be held when calling this.
+func (this *UI) getRedrawChan() chan bool {
+	if this.redrawChan == nil {
+		this.redrawChan = make(chan bool, 1)
+	}
+	return this.redrawChan
+}
+
+// Internal method which runs everything posted so far, in order.
+func (this *UI) runPosted() {
+	this.lock.Lock()
+	queue := this.postQueue
+	this.postQueue = nil
+	this.lock.Unlock()
+
+	for _, fn := range queue {
+		fn()
+	}
+}
+
+// Adds a screen to the collection.  If the screen was already activated,
+// it becomes the ui's active screen.
 func (this *UI) AddScreen(screen *Screen) {
 	this.screenHolder = append(this.screenHolder, screen)
+	screen.ui = this
+	if screen.active {
+		this.switchToScreen(screen)
+	}
+	this.Invalidate()
+}
+
+// Adds a screen to the collection under a name, which can then be used to
+// navigate to it with SwitchTo and Push.  Registering a name a second time
+// points it to the new screen.
+func (this *UI) AddNamedScreen(name string, screen *Screen) {
+	if this.namedScreens == nil {
+		this.namedScreens = make(map[string]*Screen)
+	}
+	this.namedScreens[name] = screen
+	this.AddScreen(screen)
+}
+
+// Gets a screen by the name it was registered under, or nil if
+// there's no such screen.
+func (this *UI) GetScreen(name string) *Screen {
+	return this.namedScreens[name]
+}
+
+// Makes the named screen the only active one, and forgets about the
+// navigation history (there's nothing to Pop back to afterwards).
+func (this *UI) SwitchTo(name string) error {
+	screen, err := this.getNamedScreen(name)
+	if err != nil {
+		return err
+	}
+	this.switchToScreen(screen)
+	return nil
 }
 
-// Adds a global level event binding for a meta key
-func (this *UI) AddSpecialKeyCallback(event termbox.Key, callback EventCallback) {
-	if len(this.globalKeyBindings) == 0 {
-		this.globalKeyBindings = make(map[interface{}]EventCallback)
+// Makes the named screen the only active one, remembering the current one
+// so that Pop can go back to it.
+func (this *UI) Push(name string) error {
+	screen, err := this.getNamedScreen(name)
+	if err != nil {
+		return err
+	}
+	// Nothing has gone through SwitchTo yet, so whatever is showing is the base
+	if len(this.navigationStack) == 0 {
+		if current := this.getActiveScreen(); current != nil {
+			this.navigationStack = append(this.navigationStack, current)
+		}
 	}
-	this.globalKeyBindings[event] = callback
+	this.navigationStack = append(this.navigationStack, screen)
+	this.activate(screen)
+	return nil
 }
 
-// Adds a global level event binding for a printable key
-func (this *UI) AddCharKeyCallback(char rune, callback EventCallback) {
-	if len(this.globalKeyBindings) == 0 {
-		this.globalKeyBindings = make(map[interface{}]EventCallback)
+// Goes back to the screen which was active before the last Push.
+func (this *UI) Pop() error {
+	count := len(this.navigationStack)
+	if count < 2 {
+		return ErrNoPreviousScreen
+	}
+	this.navigationStack = this.navigationStack[:count-1]
+	this.activate(this.navigationStack[count-2])
+	return nil
+}
+
+// Internal method for looking up a named screen.
+func (this *UI) getNamedScreen(name string) (*Screen, error) {
+	screen := this.namedScreens[name]
+	if screen == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScreen, name)
+	}
+	return screen, nil
+}
+
+// Internal method which activates a screen and starts the navigation
+// history over from it.  This is what Screen.Activate ends up calling.
+func (this *UI) switchToScreen(screen *Screen) {
+	this.navigationStack = []*Screen{screen}
+	this.activate(screen)
+}
+
+// Internal method which makes a screen the only active one, running the
+// hide and show hooks of the screens involved.  Screens only get resized
+// while they're active, so it gets resized on the way in in case the
+// terminal changed size while it was hidden.
+func (this *UI) activate(screen *Screen) {
+	for _, s := range this.screenHolder {
+		if s != screen && s.active {
+			s.active = false
+			s.hide()
+		}
+	}
+
+	screen.DoResize()
+	if !screen.active {
+		screen.active = true
+		screen.show()
+	}
+	this.Invalidate()
+}
+
+// Adds a global level event binding for a key press, modifiers included.
+// It's the last stop for the key, so it only runs if nothing on the active
+// screen consumed it.
+func (this *UI) AddKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler})
+}
+
+// Adds a global level event binding for a key press which runs before the
+// active screen gets the key.
+func (this *UI) AddCaptureKeyHandler(press KeyPress, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Press: press, Handler: handler, Capture: true})
+}
+
+// Binds a sequence of key presses (like "ctrl+x ctrl+s", or "g g") to a global
+// level handler, which runs once the whole sequence has been typed.  While
+// it's being typed the keys don't go anywhere else.
+func (this *UI) AddKeySequenceHandler(sequence KeySequence, handler EventHandler) *KeyBinding {
+	return this.addKeyBinding(&KeyBinding{Sequence: sequence, Handler: handler})
+}
+
+// Sets how long to wait for the next key of a key sequence.  When it runs
+// out, the keys typed so far are handled as if they weren't part of a
+// sequence.  Anything below 1 goes back to DEFAULT_SEQUENCE_TIMEOUT.
+func (this *UI) SetSequenceTimeout(timeout time.Duration) {
+	this.sequenceTimeout = timeout
+}
+
+// Sets the callback run whenever the keys typed so far of a key sequence
+// change, so they can be shown in a status line.
+func (this *UI) SetPendingKeysCallback(callback KeySequenceCallback) {
+	this.pendingKeysCallback = callback
+}
+
+// Gets the keys typed so far of a key sequence, which is empty unless the
+// ui is waiting for the rest of one.
+func (this *UI) GetPendingKeys() KeySequence {
+	pending := make(KeySequence, len(this.pendingKeys))
+	for i, ev := range this.pendingKeys {
+		pending[i] = ev.GetKeyPress()
+	}
+	return pending
+}
+
+// Registers a named action on the ui, triggered by keys given as a key
+// sequence spec (like "ctrl+s", see ParseKeySequence) or "" for no keys yet.
+// Keymaps refer to actions by name, so the keys can be changed later on with
+// BindAction or ApplyKeymap.  The bindings run when nothing underneath
+// consumed the key, like the ones added with AddKeyHandler.
+func (this *UI) AddAction(name string, keys string, handler EventHandler) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.globalKeyBindings == nil {
+		this.globalKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = addAction(this.actions, this.globalKeyBindings, this.keySequences, name, keys, handler, this.Invalidate)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
+	}
+	return err
+}
+
+// Replaces the keys bound to one of the ui's actions.  Calling this
+// with no keys leaves the action unbound.
+func (this *UI) BindAction(name string, keys ...string) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.globalKeyBindings == nil {
+		this.globalKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = bindAction(this.actions, this.globalKeyBindings, this.keySequences, name, keys)
+	if err == nil && debugLog != nil {
+		warnShadowing(this.getBindingLevel(), this.actions[name].bindings, this.getOtherBindingLevels())
 	}
-	this.globalKeyBindings[char] = callback
+	return err
 }
 
-// Set the delay time between redraws, in milliseconds
-// If this isn't set, it will default to 10 ms between refreshes, which
-// can be plenty fast enough or very slow, depending on what you want
-// your app to do.  Using actual zero, even with minimal UI elements, quickly
-// chews through CPU time.
+// Rebinds the ui's actions which are in the keymap, along with those of every screen (and their widgets).
+func (this *UI) ApplyKeymap(keymap Keymap) error {
+	if this.actions == nil {
+		this.actions = make(map[string]*namedAction)
+	}
+	if this.globalKeyBindings == nil {
+		this.globalKeyBindings = make(map[KeyPress]*KeyBinding)
+	}
+	var err error
+	this.keySequences, err = applyKeymap(this.actions, this.globalKeyBindings, this.keySequences, keymap)
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), getKeymapBindings(this.actions, keymap), this.getOtherBindingLevels())
+	}
+	if err != nil {
+		return err
+	}
+	for _, screen := range this.screenHolder {
+		err = screen.ApplyKeymap(keymap)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Internal method for adding a binding, making the map if needed.  Returns
+// the binding it was given.
+func (this *UI) addKeyBinding(binding *KeyBinding) *KeyBinding {
+	binding.redraw = this.Invalidate
+	if binding.Sequence != nil {
+		this.keySequences = addSequenceBinding(this.keySequences, binding)
+	} else {
+		if len(this.globalKeyBindings) == 0 {
+			this.globalKeyBindings = make(map[KeyPress]*KeyBinding)
+		}
+		this.globalKeyBindings[binding.Press] = binding
+	}
+
+	if debugLog != nil {
+		warnShadowing(this.getBindingLevel(), []*KeyBinding{binding}, this.getOtherBindingLevels())
+	}
+	this.Invalidate()
+	return binding
+}
+
+// Gets all of the ui's bindings - the single key ones sorted by their
+// keys, then the sequences in the order they were added.
+func (this *UI) GetBindings() []*KeyBinding {
+	return listBindings(this.globalKeyBindings, this.keySequences)
+}
+
+// Removes one of the ui's bindings, as returned when it was added.
+// Nothing happens if it isn't bound anymore.
+func (this *UI) RemoveBinding(binding *KeyBinding) {
+	this.keySequences = removeKeyBinding(this.globalKeyBindings, this.keySequences, binding)
+	this.Invalidate()
+}
+
+// Internal method for getting the level the ui's own bindings are on.
+func (this *UI) getBindingLevel() bindingLevel {
+	return bindingLevel{this, 0, this.globalKeyBindings, this.keySequences}
+}
+
+// Internal method for getting the levels below the ui which can have bindings
+// - every screen, layer and widget.
+func (this *UI) getOtherBindingLevels() []bindingLevel {
+	levels := make([]bindingLevel, 0)
+	for _, screen := range this.screenHolder {
+		levels = append(levels, screen.getBindingLevel())
+		levels = appendWidgetBindingLevels(levels, screen.widgets)
+		for _, layer := range screen.layers {
+			levels = append(levels, layer.getBindingLevel())
+			levels = appendWidgetBindingLevels(levels, layer.widgets)
+		}
+	}
+	return levels
+}
+
+// Adds a global level event binding for a meta key.  These take precedence
+// over everything on the screen.
+func (this *UI) AddSpecialKeyCallback(event termbox.Key, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Key: event}, AdaptEventCallback(callback))
+}
+
+// Adds a global level event binding for a printable key.  These take
+// precedence over everything on the screen.
+func (this *UI) AddCharKeyCallback(char rune, callback EventCallback) *KeyBinding {
+	return this.AddCaptureKeyHandler(KeyPress{Ch: char}, AdaptEventCallback(callback))
+}
+
+// Turns mouse support on or off - it's on unless this is called with false.
+// With the mouse on, clicks and the wheel get routed to the widget under the
+// pointer, but most terminals won't let the user select text with the mouse
+// anymore.  This has to be called before the ui is started.
+func (this *UI) SetMouseEnabled(enabled bool) {
+	this.mouseDisabled = !enabled
+}
+
+// Sets the theme every screen gets drawn with, unless the screen has its
+// own (see Screen.SetTheme).  Without one, the default theme gets used.
+func (this *UI) SetTheme(theme *Theme) {
+	this.theme = theme
+	this.Invalidate()
+}
+
+// Gets the theme set on the ui, nil if it uses the default one.
+func (this *UI) GetTheme() *Theme {
+	return this.theme
+}
+
+// Caps how many times per second the active screen can be redrawn.  The ui
+// only redraws when something changed (an event came in or a widget / buffer
+// was invalidated), so this only matters when changes come in faster than
+// this rate - they get batched up into a single frame.  Anything below 1
+// goes back to the default of DEFAULT_MAX_FRAME_RATE.
+func (this *UI) SetMaxFrameRate(fps int) {
+	if fps < 1 {
+		this.frameInterval = 0
+	} else {
+		this.frameInterval = time.Second / time.Duration(fps)
+	}
+}
+
+// Set the minimum delay between redraws, in milliseconds.
+//
+// Deprecated: the ui no longer redraws on a timer, use SetMaxFrameRate instead.
+// This is kept around so older applications still build, and is the same
+// as setting a frame rate of 1000 / delay.
 func (this *UI) SetDrawDelay(delay int) {
-	this.redrawDelay = time.Duration(delay)
+	this.frameInterval = time.Duration(delay) * time.Millisecond
+}
+
+// Internal method for getting the minimum time between two frames.
+func (this *UI) getFrameInterval() time.Duration {
+	if this.frameInterval <= 0 {
+		return time.Second / DEFAULT_MAX_FRAME_RATE
+	}
+	return this.frameInterval
+}
+
+// Gets the screen which is currently active, or nil if there isn't one.
+func (this *UI) GetActiveScreen() *Screen {
+	return this.getActiveScreen()
 }
 
 // Internal method for getting the active screen of the UI.
 // Returns nil if nothing comes back as active.
 func (this *UI) getActiveScreen() *Screen {
-	var screen *Screen
 	for _, s := range this.screenHolder {
 		if s.IsActive() {
-			screen = s
+			return s
 		}
 	}
-	return screen
+	return nil
 }
 
-// Performs the UI's main loop.  Fires up the termbox ui,
-// creates an event queue, starts polling it for events and then
-// hands off those events to the mapped handlers, starting at top level
-// (this ui object) and moving down to the screen and widget level through
-// handlers.
-func (this *UI) mainLoop(quitChan chan bool) {
-	err := termbox.Init()
-	if err != nil {
-		panic(err)
+// Hands an event off to the mapped handlers, starting at top level (this ui
+// object) and moving down to the screen and widget level.  The main loop calls
+// this for every event the backend produces, but it can also be called directly
+// (from tests, for example) to simulate input without running the loop.
+func (this *UI) HandleEvent(ev Event) {
+	screen := this.getActiveScreen()
+	if screen == nil {
+		return
+	}
+
+	switch ev.Type {
+	case EVENT_RESIZE:
+		screen.DoResize()
+		return
+
+	case EVENT_MOUSE:
+		// The screen figures out which widget is under the pointer.
+		// Dragging isn't supported, so motion events get ignored.
+		if ev.Mod&MOD_MOTION != 0 {
+			return
+		}
+
+	case EVENT_KEY:
+		if this.handleKeySequence(screen, ev) {
+			return
+		}
+
+	case EVENT_PASTE, EVENT_CUSTOM:
+
+	default:
+		return
+	}
+
+	this.dispatch(screen, ev)
+}
+
+// Internal method which sends an event down to the screen.  Checks for top
+// level keybindings on the way down to the screen and on the way back up.
+// Calls the appropriate handler and passes an instance of the ui to it
+func (this *UI) dispatch(screen *Screen, ev Event) {
+	if runKeyBinding(this.globalKeyBindings, this, ev, true) {
+		return
+	}
+	if screen.HandleEvents(ev) {
+		return
+	}
+	runKeyBinding(this.globalKeyBindings, this, ev, false)
+}
+
+// Internal method which deals with key sequences, returning whether or not
+// the key got used up by one.  If it didn't it gets handled normally.
+//
+// A key which completes a sequence runs that sequence's handler, and one
+// which starts or continues a sequence waits for the next key.  Otherwise
+// the keys typed so far (if any) weren't a sequence after all, and they get
+// handled normally before the new key.
+func (this *UI) handleKeySequence(screen *Screen, ev Event) bool {
+	levels := append(screen.getBindingLevels(), this.getBindingLevel())
+
+	typed := append(this.GetPendingKeys(), ev.GetKeyPress())
+	isPrefix := false
+	for _, level := range levels {
+		for _, binding := range level.sequences {
+			if binding.Sequence.Equals(typed) {
+				if binding.Handler(level.source, ev) {
+					this.setPendingKeys(nil)
+					return true
+				}
+			} else if binding.Sequence.HasPrefix(typed) {
+				isPrefix = true
+			}
+		}
 	}
 
-	eventQueue := make(chan termbox.Event)
+	if isPrefix {
+		this.setPendingKeys(append(this.pendingKeys, ev))
+		return true
+	}
+	if len(this.pendingKeys) == 0 {
+		return false
+	}
+
+	// The new key might start a sequence of its own
+	this.flushPendingKeys()
+	return this.handleKeySequence(screen, ev)
+}
+
+// Internal method for changing the keys typed so far of a key sequence,
+// which (re)starts the timer for giving up on it.
+func (this *UI) setPendingKeys(keys []Event) {
+	changed := len(keys) > 0 || len(this.pendingKeys) > 0
+	this.pendingKeys = keys
+	this.pendingGeneration++
+	if this.pendingTimer != nil {
+		this.pendingTimer.Stop()
+		this.pendingTimer = nil
+	}
+
+	if len(keys) > 0 {
+		generation := this.pendingGeneration
+		this.pendingTimer = time.AfterFunc(this.getSequenceTimeout(), func() {
+			this.Post(func() {
+				if this.pendingGeneration == generation {
+					this.flushPendingKeys()
+				}
+			})
+		})
+	}
+
+	if changed {
+		if this.pendingKeysCallback != nil {
+			this.pendingKeysCallback(this, this.GetPendingKeys())
+		}
+		this.Invalidate()
+	}
+}
+
+// Internal method which gives up on the key sequence being typed, handling
+// its keys normally instead.
+func (this *UI) flushPendingKeys() {
+	keys := this.pendingKeys
+	this.setPendingKeys(nil)
 
-	// Read termbox events async on channel
+	screen := this.getActiveScreen()
+	if screen == nil {
+		return
+	}
+	for _, ev := range keys {
+		this.dispatch(screen, ev)
+	}
+}
+
+// Internal method for getting how long to wait for the next key of a sequence.
+func (this *UI) getSequenceTimeout() time.Duration {
+	if this.sequenceTimeout <= 0 {
+		return DEFAULT_SEQUENCE_TIMEOUT
+	}
+	return this.sequenceTimeout
+}
+
+// Performs the UI's main loop.  Creates an event queue, starts polling the
+// backend for events and then hands off those events to the mapped handlers,
+// starting at top level (this ui object) and moving down to the screen and
+// widget level through handlers.  Returns once the ui is shut down, after
+// the polling goroutine has stopped.
+func (this *UI) mainLoop(ctx context.Context, backend Backend) {
+	eventQueue := make(chan Event)
+	stopPolling := make(chan bool)
+	pollingDone := make(chan bool)
+
+	// Read backend events async on channel.  Once the loop is gone, events
+	// are dropped until the backend gets interrupted, since that's the only
+	// thing which can get it out of PollEvent.
 	go func() {
+		defer close(pollingDone)
 		for {
-			eventQueue <- termbox.PollEvent()
+			ev := backend.PollEvent()
+			if ev.Type == EVENT_INTERRUPT {
+				return
+			}
+			select {
+			case eventQueue <- ev:
+			case <-stopPolling:
+			}
 		}
 	}()
 
-	// Take care of exiting from the entire UI here
-	go func() {
-		for {
-			quitSig := <-this.uiShutdownChan
-			if quitSig {
+	defer func() {
+		close(stopPolling)
+		backend.Interrupt()
+		<-pollingDone
+	}()
 
-				// Shutdown the termbox UI
-				termbox.Close()
+	// Main event handling loop.  It blocks until either an event comes in
+	// or something invalidates the ui, and only redraws when it's dirty.  If
+	// the last frame was drawn too recently, the redraw is pushed back with a
+	// timer so that we never go over the max frame rate.
+	dirty := true
+	var lastDraw time.Time
+	var frameTimer <-chan time.Time
 
-				// Send the quit signal back to the ui caller
-				quitChan <- true
+	this.lock.Lock()
+	postChan := this.getPostChan()
+	shutdownChan := this.getShutdownChan()
+	redrawChan := this.getRedrawChan()
+	this.lock.Unlock()
+
+	for {
+		if dirty && frameTimer == nil {
+			wait := this.getFrameInterval() - time.Since(lastDraw)
+			if wait > 0 {
+				frameTimer = time.After(wait)
+			} else {
+				screen := this.getActiveScreen()
+				if screen != nil {
+					screen.Draw()
+				}
+				lastDraw = time.Now()
+				dirty = false
 			}
 		}
-	}()
 
-	// Main event handling loop
-	for {
 		select {
 		case ev := <-eventQueue:
+			this.HandleEvent(ev)
 
-			// Handle resize event
-			if ev.Type == termbox.EventResize {
-				this.getActiveScreen().DoResize()
-			}
+			// Callbacks can change just about anything, so always
+			// redraw after handling an event
+			dirty = true
 
-			// Check for top level keybindings
-			// Calls the appropriate callback and passes an instance of the ui to it
-
-			if ev.Type == termbox.EventKey && this.globalKeyBindings[ev.Key] != nil {
-				this.globalKeyBindings[ev.Key](this, ev.Key)
-			} else if ev.Type == termbox.EventKey && this.globalKeyBindings[ev.Ch] != nil {
-				this.globalKeyBindings[ev.Ch](this, ev.Ch)
-			} else if ev.Type == termbox.EventKey && ev.Key != 0 {
-				this.getActiveScreen().HandleEvents(ev.Key)
-			} else if ev.Type == termbox.EventKey && ev.Ch != 0 {
-				this.getActiveScreen().HandleEvents(ev.Ch)
-			}
+		case <-redrawChan:
+			dirty = true
 
-		default:
-			this.getActiveScreen().Draw()
-			if this.redrawDelay < 1 {
-				time.Sleep(10 * time.Millisecond)
-			} else {
-				time.Sleep(this.redrawDelay * time.Millisecond)
-			}
+		case <-postChan:
+			this.runPosted()
+			dirty = true
+
+		case <-frameTimer:
+			frameTimer = nil
+
+		case <-shutdownChan:
+			return
+
+		case <-ctx.Done():
+			return
 		}
 	}
 }
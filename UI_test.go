@@ -0,0 +1,138 @@
+package tbuikit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Runs a ui with one screen on a memory backend in the background, for as
+// long as the test does.
+func startUI(t *testing.T, width, height int) (*UI, *Screen, *MemoryBackend) {
+	t.Helper()
+
+	mem := CreateMemoryBackend(width, height)
+	ui := new(UI)
+	ui.SetBackend(mem)
+	screen := new(Screen)
+	ui.AddScreen(screen)
+	screen.Activate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ui.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("run failed: %v", err)
+		}
+	})
+	return ui, screen, mem
+}
+
+// Waits for something drawn on the backend to show up, failing the test if
+// it takes too long.
+func waitFor(t *testing.T, what string, check func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !check() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPostFromManyGoroutines(t *testing.T) {
+	ui, _, _ := startUI(t, 10, 3)
+
+	// Only ever touched on the main loop, so the race detector catches it
+	// if a posted function runs anywhere else
+	count := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ui.Post(func() {
+					count++
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Everything posted before this has run by the time it does
+	total := 0
+	ui.Invoke(func() {
+		total = count
+	})
+	if total != 1000 {
+		t.Errorf("expected all 1000 posted functions to run, got %d", total)
+	}
+}
+
+func TestInvokeWaitsForTheFunction(t *testing.T) {
+	ui, screen, _ := startUI(t, 10, 3)
+
+	ran := false
+	var active *Screen
+	ui.Invoke(func() {
+		time.Sleep(10 * time.Millisecond)
+		active = ui.GetActiveScreen()
+		ran = true
+	})
+	if !ran {
+		t.Fatal("expected Invoke to return after the function ran")
+	}
+	if active != screen {
+		t.Errorf("expected the function to see the active screen")
+	}
+}
+
+func TestPostEventReachesTheScreen(t *testing.T) {
+	ui, screen, _ := startUI(t, 10, 3)
+
+	var got interface{}
+	screen.AddKeyHandler(KeyPress{Ch: 'x'}, func(source interface{}, event Event) bool {
+		got = source
+		return true
+	})
+	ui.PostEvent(CreateCharEvent('x', 0))
+
+	var handled interface{}
+	ui.Invoke(func() {
+		handled = got
+	})
+	if handled != screen {
+		t.Errorf("expected the screen's handler to get the key, got %v", handled)
+	}
+}
+
+func TestAddWidgetsWhileDrawing(t *testing.T) {
+	ui, screen, mem := startUI(t, 20, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		row := i
+		go func() {
+			defer wg.Done()
+			ui.Post(func() {
+				screen.AddWidget(NewLabel("label", WithLayout(at(0, 9, row-1, row))))
+			})
+			ui.Invalidate()
+		}()
+	}
+	wg.Wait()
+
+	waitFor(t, "the last label to be drawn", func() bool {
+		return mem.GetRectText(CreateRectangle(1, 5, 9, 9))[0] == "label"
+	})
+}
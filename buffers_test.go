@@ -0,0 +1,101 @@
+package tbuikit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+// The buffers get written to from other goroutines while the main loop draws
+// them, so these run a ui and hammer them - go test -race catches it if any
+// of that isn't properly locked.
+
+func TestStringBufferAddWhileDrawing(t *testing.T) {
+	ui, screen, mem := startUI(t, 30, 6)
+	buffer := new(StringBuffer)
+	buffer.Prepare(100)
+	ui.Invoke(func() {
+		screen.AddWidget(NewStringDisplay(buffer, WithLayout(at(0, 29, 0, 5))))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		writer := i
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				buffer.Add(fmt.Sprintf("writer %d line %d", writer, j))
+			}
+		}()
+	}
+	wg.Wait()
+	buffer.Add("done")
+
+	if lines := buffer.GetContents(28, 1000); len(lines) != 100 {
+		t.Errorf("expected the buffer to be cut down to its capacity of 100 lines, got %d", len(lines))
+	}
+	waitFor(t, "the last line to be drawn", func() bool {
+		return strings.TrimSpace(mem.GetRectText(CreateRectangle(1, 28, 4, 4))[0]) == "done"
+	})
+}
+
+func TestColorizedStringBufferAddWhileDrawing(t *testing.T) {
+	ui, screen, mem := startUI(t, 30, 6)
+	buffer := new(ColorizedStringBuffer)
+	buffer.Prepare(100)
+	ui.Invoke(func() {
+		screen.AddWidget(NewColorizedText(buffer, WithLayout(at(0, 29, 0, 5))))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		writer := i
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				buffer.Add(&ColorizedString{Color: termbox.ColorGreen, Text: fmt.Sprintf("writer %d line %d", writer, j)})
+			}
+		}()
+	}
+	wg.Wait()
+	buffer.Add(&ColorizedString{Color: termbox.ColorRed, Text: "done"})
+
+	waitFor(t, "the last line to be drawn", func() bool {
+		return strings.TrimSpace(mem.GetRectText(CreateRectangle(1, 28, 4, 4))[0]) == "done"
+	})
+	if cell := mem.GetCell(1, 4); cell.Fg != COLOR_RED {
+		t.Errorf("expected the last line in red, got %+v", cell)
+	}
+}
+
+func TestTextInputBufferAddWhileDrawing(t *testing.T) {
+	ui, screen, mem := startUI(t, 30, 3)
+	buffer := new(TextInputBuffer)
+	ui.Invoke(func() {
+		screen.AddWidget(NewTextInput(buffer, WithLayout(at(0, 29, 0, 2))))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				buffer.Add('x')
+			}
+		}()
+	}
+	wg.Wait()
+
+	if text := buffer.GetText(); text != strings.Repeat("x", 20) {
+		t.Errorf("expected every character to make it in, got %q", text)
+	}
+	waitFor(t, "the text to be drawn", func() bool {
+		return mem.GetRectText(CreateRectangle(1, 20, 1, 1))[0] == strings.Repeat("x", 20)
+	})
+}
@@ -1,5 +1,9 @@
 package tbuikit
 
+import (
+	"time"
+)
+
 // Declare some library wide constants
 const (
 	// Positions
@@ -9,3 +13,104 @@ const (
 	BOTTOM_RIGHT ScreenPosition = 3
 	CENTER       ScreenPosition = 4
 )
+
+// Event types
+const (
+	EVENT_NONE      EventType = 0
+	EVENT_KEY       EventType = 1
+	EVENT_MOUSE     EventType = 2
+	EVENT_RESIZE    EventType = 3
+	EVENT_PASTE     EventType = 4
+	EVENT_CUSTOM    EventType = 5
+	EVENT_INTERRUPT EventType = 6
+)
+
+// Modifiers
+const (
+	MOD_ALT    Modifier = 1
+	MOD_MOTION Modifier = 2
+)
+
+// Directions
+const (
+	UP    Direction = 0
+	DOWN  Direction = 1
+	LEFT  Direction = 2
+	RIGHT Direction = 3
+)
+
+// Mouse actions
+const (
+	MOUSE_LEFT       MouseAction = 0
+	MOUSE_MIDDLE     MouseAction = 1
+	MOUSE_RIGHT      MouseAction = 2
+	MOUSE_RELEASE    MouseAction = 3
+	MOUSE_WHEEL_UP   MouseAction = 4
+	MOUSE_WHEEL_DOWN MouseAction = 5
+)
+
+// The color everything underneath a layer which dims the
+// background gets redrawn in
+const DIM_COLOR = COLOR_BLACK | Color(ATTR_BOLD)
+
+// How many lines a display widget scrolls per notch of the mouse wheel
+const WHEEL_SCROLL_LINES = 3
+
+// The frame rate the main loop is capped at unless the application
+// asks for something else with UI.SetMaxFrameRate
+const DEFAULT_MAX_FRAME_RATE = 60
+
+// How long the ui waits for the next key of a key sequence before giving up
+const DEFAULT_SEQUENCE_TIMEOUT = time.Second
+
+// Widget roles, which themes style widgets by
+const (
+	ROLE_DEFAULT WidgetRole = 0
+	ROLE_BUTTON  WidgetRole = 1
+	ROLE_LABEL   WidgetRole = 2
+	ROLE_INPUT   WidgetRole = 3
+	ROLE_DISPLAY WidgetRole = 4
+	ROLE_PANEL   WidgetRole = 5
+	ROLE_FOOTER  WidgetRole = 6
+	ROLE_DIALOG  WidgetRole = 7
+	ROLE_HEADING WidgetRole = 8
+)
+
+// Widget states
+const (
+	STATE_NORMAL   WidgetState = 0
+	STATE_FOCUSED  WidgetState = 1
+	STATE_DISABLED WidgetState = 2
+)
+
+// Cell colors.  COLOR_DEFAULT is whatever the terminal uses by default.
+const (
+	COLOR_DEFAULT       Color = 0
+	COLOR_BLACK         Color = 1
+	COLOR_RED           Color = 2
+	COLOR_GREEN         Color = 3
+	COLOR_YELLOW        Color = 4
+	COLOR_BLUE          Color = 5
+	COLOR_MAGENTA       Color = 6
+	COLOR_CYAN          Color = 7
+	COLOR_WHITE         Color = 8
+	COLOR_DARK_GRAY     Color = 9
+	COLOR_LIGHT_RED     Color = 10
+	COLOR_LIGHT_GREEN   Color = 11
+	COLOR_LIGHT_YELLOW  Color = 12
+	COLOR_LIGHT_BLUE    Color = 13
+	COLOR_LIGHT_MAGENTA Color = 14
+	COLOR_LIGHT_CYAN    Color = 15
+	COLOR_LIGHT_GRAY    Color = 16
+)
+
+// Text attributes.  Not every terminal supports all of them.
+const (
+	ATTR_BOLD      Attr = 1 << 9
+	ATTR_BLINK     Attr = 1 << 10
+	ATTR_HIDDEN    Attr = 1 << 11
+	ATTR_DIM       Attr = 1 << 12
+	ATTR_UNDERLINE Attr = 1 << 13
+	ATTR_CURSIVE   Attr = 1 << 14
+	ATTR_REVERSE   Attr = 1 << 15
+)
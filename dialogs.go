@@ -0,0 +1,177 @@
+package tbuikit
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// Ready-made modal dialogs, built out of the regular widgets and shown as a
+// layer on top of the active screen.  In all of them Tab and the arrow keys
+// move between the buttons, Enter presses the selected one and Esc cancels.
+//
+// They're drawn by the theme of the screen they're shown on - the frame and
+// title as ROLE_DIALOG, and the rest as the regular widgets they are.
+
+// The narrowest a dialog gets, so short messages don't end up in a tiny box
+const dialogMinWidth = 30
+
+// Asks a yes / no question.  The returned channel gets true if the user
+// pressed OK, and false if they pressed Cancel or escaped out of it.
+func (this *UI) Confirm(title, msg string) <-chan bool {
+	answer := make(chan bool, 1)
+	this.Post(func() {
+		this.showDialog(title, msg, nil, []string{"OK", "Cancel"}, func(pressed int) {
+			answer <- pressed == 0
+		})
+	})
+	return answer
+}
+
+// Asks the user to type something in, with the input field starting out
+// with defaultText.  The returned channel gets what was typed if the user
+// pressed OK (or Enter in the input field), and gets closed without a value
+// if they pressed Cancel or escaped out of it.
+func (this *UI) Prompt(title, defaultText string) <-chan string {
+	answer := make(chan string, 1)
+	input := new(TextInputBuffer)
+	input.SetText(defaultText)
+	this.Post(func() {
+		this.showDialog(title, "", input, []string{"OK", "Cancel"}, func(pressed int) {
+			if pressed == 0 {
+				answer <- input.GetText()
+			}
+			close(answer)
+		})
+	})
+	return answer
+}
+
+// Shows a message with a single OK button.  The returned channel gets true
+// once the user has dismissed it.
+func (this *UI) Alert(msg string) <-chan bool {
+	answer := make(chan bool, 1)
+	this.Post(func() {
+		this.showDialog("", msg, nil, []string{"OK"}, func(pressed int) {
+			answer <- true
+		})
+	})
+	return answer
+}
+
+// Internal method which builds a dialog and pushes it onto the active screen.
+// From top to bottom, the dialog has a title line and a message line (both
+// left out when empty), an optional input field and a row of buttons.
+// resolve gets called exactly once, with the index of the button which was
+// pressed or -1 if the dialog was escaped out of (or if there's no screen to
+// show it on).  Has to run on the main loop.
+func (this *UI) showDialog(title, message string, input *TextInputBuffer, buttons []string, resolve func(pressed int)) {
+	screen := this.getActiveScreen()
+	if screen == nil {
+		resolve(-1)
+		return
+	}
+
+	// Figure out how big the dialog needs to be: a border on each side, the
+	// title and message lines, 3 lines for the input and 3 for the buttons
+	buttonsWidth := 0
+	for _, text := range buttons {
+		buttonsWidth += TextWidth(text) + 5
+	}
+	width := dialogMinWidth
+	for _, w := range []int{TextWidth(title) + 4, TextWidth(message) + 4, buttonsWidth + 3} {
+		if w > width {
+			width = w
+		}
+	}
+	row := 1
+	if title != "" {
+		row++
+	}
+	messageRow := row
+	if message != "" {
+		row++
+	}
+	inputRow := row
+	if input != nil {
+		row += 3
+	}
+	buttonsRow := row
+	height := buttonsRow + 4
+
+	// Everything gets positioned relative to the dialog's frame, which is
+	// centered on the screen
+	frame := func() (x1, x2, y1, y2 int) {
+		screenWidth, screenHeight := this.GetSize()
+		x1 = (screenWidth - width) / 2
+		y1 = (screenHeight - height) / 2
+		return x1, x1 + width - 1, y1, y1 + height - 1
+	}
+	inFrame := func(dx1, dx2, dy1, dy2 int) CalcFunction {
+		return func() (int, int, int, int) {
+			x1, _, y1, _ := frame()
+			return x1 + dx1, x1 + dx2, y1 + dy1, y1 + dy2
+		}
+	}
+
+	layer := CreateLayer(true, true)
+
+	layer.AddWidget(NewLabel(title, WithRole(ROLE_DIALOG), WithBorder(true), WithFillBackground(true), WithLayout(frame)))
+	layer.AddWidget(NewLabel(message, WithTextPosition(CENTER), WithLayout(inFrame(1, width-2, messageRow, messageRow))))
+
+	if input != nil {
+		layer.AddWidget(NewTextInput(input, WithLayout(inFrame(1, width-2, inputRow, inputRow+2))))
+	}
+
+	resolved := false
+	finish := func(pressed int) {
+		if !resolved {
+			resolved = true
+			layer.Dismiss()
+			resolve(pressed)
+		}
+	}
+
+	// The buttons are centered at the bottom, with a space between each
+	buttonWidgets := make([]Widget, 0)
+	x := (width - buttonsWidth) / 2
+	for i, text := range buttons {
+		pressed := i
+		buttonWidth := TextWidth(text) + 4
+		button := NewButton(text, WithLayout(inFrame(x, x+buttonWidth-1, buttonsRow, buttonsRow+2)), OnPress(func(interface{}, Event) {
+			finish(pressed)
+		}))
+		layer.AddWidget(button)
+		buttonWidgets = append(buttonWidgets, button)
+		x += buttonWidth + 1
+	}
+
+	next := func(interface{}, Event) bool {
+		screen.SelectNextWidget()
+		return true
+	}
+	layer.AddKeyHandler(KeyPress{Key: termbox.KeyTab}, next)
+	layer.AddKeyHandler(KeyPress{Key: termbox.KeyArrowRight}, next)
+	layer.AddKeyHandler(KeyPress{Key: termbox.KeyArrowLeft}, func(interface{}, Event) bool {
+		screen.SelectPreviousWidget()
+		return true
+	})
+	layer.AddKeyHandler(KeyPress{Key: termbox.KeyEsc}, func(interface{}, Event) bool {
+		finish(-1)
+		return true
+	})
+
+	// Enter presses whichever button is selected, and accepts the dialog
+	// from the input field
+	layer.AddKeyHandler(KeyPress{Key: termbox.KeyEnter}, func(interface{}, Event) bool {
+		selected, _ := screen.GetCurrentSelectedWidget()
+		for i, button := range buttonWidgets {
+			if button == selected {
+				finish(i)
+				return true
+			}
+		}
+		finish(0)
+		return true
+	})
+
+	screen.PushLayer(layer)
+}
@@ -0,0 +1,103 @@
+package tbuikit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Starts a ui with a selected button on its screen, which the dialogs should
+// hand the focus back to once they're gone.
+func startUIWithButton(t *testing.T) (*UI, *MemoryBackend, *ButtonWidget) {
+	t.Helper()
+
+	ui, screen, mem := startUI(t, 40, 12)
+	button := NewButton("Back", WithLayout(at(0, 9, 0, 2)))
+	ui.Invoke(func() {
+		screen.AddWidget(button)
+		screen.Focus(button)
+	})
+	return ui, mem, button
+}
+
+// Waits for a dialog to show up as a modal layer on the active screen.
+func waitForDialog(t *testing.T, ui *UI) {
+	t.Helper()
+
+	waitFor(t, "the dialog to show up", func() bool {
+		shown := false
+		ui.Invoke(func() {
+			shown = ui.GetActiveScreen().getModalLayer() != nil
+		})
+		return shown
+	})
+}
+
+// Checks that the button has the focus back and that no dialog is left up.
+func assertFocusRestored(t *testing.T, ui *UI, button *ButtonWidget) {
+	t.Helper()
+
+	var selected, modal bool
+	ui.Invoke(func() {
+		selected = button.IsSelected()
+		modal = ui.GetActiveScreen().getModalLayer() != nil
+	})
+	if modal {
+		t.Errorf("expected the dialog to be dismissed")
+	}
+	if !selected {
+		t.Errorf("expected the button selected before the dialog to get the focus back")
+	}
+}
+
+func TestConfirmEscCancels(t *testing.T) {
+	ui, mem, button := startUIWithButton(t)
+
+	answer := ui.Confirm("Quit", "Really quit?")
+	waitForDialog(t, ui)
+	mem.InjectKey(termbox.KeyEsc)
+
+	select {
+	case ok := <-answer:
+		if ok {
+			t.Errorf("expected Esc to answer false")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the answer")
+	}
+	assertFocusRestored(t, ui, button)
+}
+
+func TestPromptEscClosesWithoutValue(t *testing.T) {
+	ui, mem, button := startUIWithButton(t)
+
+	answer := ui.Prompt("Name", "typed")
+	waitForDialog(t, ui)
+	mem.InjectKey(termbox.KeyEsc)
+
+	select {
+	case text, ok := <-answer:
+		if ok {
+			t.Errorf("expected the channel to close without a value, got %q", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the answer")
+	}
+	assertFocusRestored(t, ui, button)
+}
+
+func TestAlertEscDismisses(t *testing.T) {
+	ui, mem, button := startUIWithButton(t)
+
+	answer := ui.Alert("Saved")
+	waitForDialog(t, ui)
+	mem.InjectKey(termbox.KeyEsc)
+
+	select {
+	case <-answer:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the alert to be dismissed")
+	}
+	assertFocusRestored(t, ui, button)
+}
@@ -2,68 +2,203 @@ package tbuikit
 
 import (
 	"fmt"
+	"github.com/mattn/go-runewidth"
 	"github.com/nsf/termbox-go"
+	"io"
+	"log"
+	"sync"
 )
 
 // Basic functions
 
-// Prints a string to a termbox buffer.
-// Takes the height and starting x position and then prints the string RTL
-func TermboxPrint(x, y int, fg, bg termbox.Attribute, msg string) {
+// The uis whose main loops are running, which Invalidate wakes up.
+var runningLock sync.Mutex
+var runningUIs = make(map[*UI]bool)
+
+// Marks every running ui as dirty so their active screens get redrawn the
+// next time their main loops get a chance to.  The library's widgets and
+// buffers redraw only the ui they're on instead (see Screen.Invalidate and
+// UI.Invalidate), falling back on this while they aren't on one, so
+// applications only need to call it when they change something the library
+// can't see (like the state a CalcFunction depends on).
+func Invalidate() {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+
+	for ui := range runningUIs {
+		ui.Invalidate()
+	}
+}
+
+// Internal function for keeping track of whether or not a ui's main loop
+// is running, for Invalidate.
+func setRunning(ui *UI, running bool) {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+
+	if running {
+		runningUIs[ui] = true
+	} else {
+		delete(runningUIs, ui)
+	}
+}
+
+// The backend used by every ui without one of its own (see UI.SetBackend),
+// and by the functions which don't know about a ui, like GetTermboxWidth and
+// FullScreen.  Defaults to termbox.
+var backend Backend = CreateTermboxBackend()
+
+// Replaces the default backend, which every ui without one of its own (see
+// UI.SetBackend) draws through and reads events from.  This has to be done
+// before the ui is started.
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// Gets the default backend.
+func GetBackend() Backend {
+	return backend
+}
+
+// The part of a widget (or a buffer) which knows what screen it's on, so
+// that redrawing it only wakes up the ui that screen belongs to.  Screens,
+// layers and panels fill it in as widgets get added to them.
+type screenLink struct {
+	screen *Screen
+}
+
+// Internal method for telling the widget what screen it's on.
+func (this *screenLink) setScreen(screen *Screen) {
+	this.screen = screen
+}
+
+// Internal method for getting the screen the widget is on, or nil if it
+// isn't on one (yet).
+func (this *screenLink) getScreen() *Screen {
+	return this.screen
+}
+
+// Marks whatever the widget is on as dirty, or every running ui if it
+// isn't on a screen (yet).
+func (this *screenLink) invalidate() {
+	if this.screen != nil {
+		this.screen.Invalidate()
+	} else {
+		Invalidate()
+	}
+}
+
+// Internal function which tells a widget (and anything inside of it) what
+// screen it's on, if it's one of the kinds which care.
+func linkToScreen(widget Widget, screen *Screen) {
+	linked, ok := widget.(interface{ setScreen(*Screen) })
+	if ok {
+		linked.setScreen(screen)
+	}
+}
+
+// Where debug messages go, nil when debug mode is off.
+var debugLog *log.Logger
+
+// Turns on debug mode, writing warnings about things which are probably
+// mistakes (like a keybinding shadowing another one) to w.  Since the ui
+// owns the terminal this should usually be a file.  Passing nil turns debug
+// mode back off.  Like SetBackend, this has to be done before the ui is started.
+func SetDebugLog(w io.Writer) {
+	if w == nil {
+		debugLog = nil
+	} else {
+		debugLog = log.New(w, "tbuikit: ", log.LstdFlags)
+	}
+}
+
+// Writes a message to the debug log, if debug mode is on.
+func debugf(format string, args ...interface{}) {
+	if debugLog != nil {
+		debugLog.Printf(format, args...)
+	}
+}
+
+// Prints a string to a surface.
+// Takes the height and starting x position and then prints the string RTL.
+// Wide (East Asian) characters take up two cells, and zero width ones like
+// combining marks get skipped since a cell can only hold one rune.
+func SurfacePrint(surface Surface, x, y int, fg, bg Color, msg string) {
 	for _, c := range msg {
-		termbox.SetCell(x, y, c, fg, bg)
-		x++
+		width := runewidth.RuneWidth(c)
+		if width == 0 {
+			continue
+		}
+		surface.SetCell(x, y, c, fg, bg)
+		x += width
 	}
 }
 
+// Same as SurfacePrint but takes a formatted string
+func SurfacePrintf(surface Surface, x, y int, fg, bg Color, format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	SurfacePrint(surface, x, y, fg, bg, s)
+}
+
+// Prints a string to the backend's buffer.
+// Takes the height and starting x position and then prints the string RTL
+func TermboxPrint(x, y int, fg, bg termbox.Attribute, msg string) {
+	SurfacePrint(GetBackend(), x, y, fromTermbox(fg), fromTermbox(bg), msg)
+}
+
 // Same as print_tb but takes a formatted string
 func TermboxPrintf(x, y int, fg, bg termbox.Attribute, format string, args ...interface{}) {
-	s := fmt.Sprintf(format, args...)
-	TermboxPrint(x, y, fg, bg, s)
+	SurfacePrintf(GetBackend(), x, y, fromTermbox(fg), fromTermbox(bg), format, args...)
 }
 
-// Gets only the termbox's width - since it constantly returns both values
+// Gets only the backend's width - since it constantly returns both values
 // and we don't always want both, this is basically a wrapper around
 // _ = h
 func GetTermboxWidth() int {
-	w, h := termbox.Size()
+	w, h := GetBackend().Size()
 	_ = h
 	return w
 }
 
 // Same as getTermboxWith but for height
 func GetTermboxHeight() int {
-	w, h := termbox.Size()
+	w, h := GetBackend().Size()
 	_ = w
 	return h
 }
 
-// Move the cursor to the end of the provided string,
+// A CalcFunction covering the whole terminal, for the outermost layout
+// container (see CreateVBox) or a widget which fills the screen.  This goes
+// by the default backend - UI.FullScreen is the one for a ui with its own.
+func FullScreen() (x1, x2, y1, y2 int) {
+	w, h := GetBackend().Size()
+	return 0, w - 1, 0, h - 1
+}
+
+// Move the surface's cursor to the end of the provided string,
 // starting at a given xOffset (not all widgets start at
 // the left edge of the screen!)
+func SurfaceMoveCursor(surface Surface, xOffset, y int, bufferLine string) {
+	length := TextWidth(bufferLine)
+	surface.SetCursor(xOffset+length+1, y)
+}
+
+// Same as SurfaceMoveCursor, but moves the backend's cursor
 func MoveCursor(xOffset, y int, bufferLine string) {
-	length := len(bufferLine)
-	termbox.SetCursor(xOffset+length+1, y)
+	SurfaceMoveCursor(GetBackend(), xOffset, y, bufferLine)
+}
+
+// Gets how many cells a string takes up on the terminal, which isn't the
+// same as its length - wide (East Asian) characters take up two cells, and
+// combining marks none.
+func TextWidth(text string) int {
+	return runewidth.StringWidth(text)
 }
 
 // Splits up a string into a slice of strings, making "lines" of
-// text to display.  The criteria is to split by width (buffer widget width)
-//
-// TODO It'd be nice to split on whitespace instead of in the middle of a word!
+// text to display.  The criteria is to split by width (buffer widget width),
+// in cells, breaking between words where it can - see WrapText, which this
+// is without the indent.
 func SplitBufferLines(stringToSplit string, width int) []string {
-	lines := make([]string, 0)
-	temp := ""
-	j := 1
-	for i := 0; i < len(stringToSplit); i++ {
-		temp += string(stringToSplit[i])
-		j++
-		if j == width {
-			j = 0
-			lines = append(lines, temp)
-			temp = ""
-		} else if i == len(stringToSplit)-1 {
-			lines = append(lines, temp)
-		}
-	}
-	return lines
+	return WrapText(stringToSplit, width, 0)
 }
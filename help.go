@@ -0,0 +1,188 @@
+package tbuikit
+
+import (
+	"github.com/nsf/termbox-go"
+	"strings"
+)
+
+// The keybinding help overlay, and figuring out which bindings are in effect
+// so it (and the footer widget) can list them.
+
+// The group bindings without one get listed under
+const helpDefaultGroup = "General"
+
+// The description and group given to an action with UI.DescribeAction
+type actionDescription struct {
+	description string
+	group       string
+}
+
+// Describes an action (see AddAction) for the help overlay and the footer,
+// wherever it's registered.  A description set on one of the action's
+// bindings directly wins over this one.
+func (this *UI) DescribeAction(name, description, group string) {
+	if this.actionDescriptions == nil {
+		this.actionDescriptions = make(map[string]actionDescription)
+	}
+	this.actionDescriptions[name] = actionDescription{description, group}
+	this.Invalidate()
+}
+
+// Gets a binding's description and group, falling back on the description
+// of its action.  Bindings without any get an empty description.
+func (this *UI) GetBindingDescription(binding *KeyBinding) (description, group string) {
+	description, group = binding.Description, binding.Group
+	info, ok := this.actionDescriptions[binding.Action]
+	if description == "" && ok {
+		description, group = info.description, info.group
+	}
+	return description, group
+}
+
+// Gets the bindings which are currently in effect, merged from the selected
+// widget, the active screen (or its modal layer) and the ui itself.  They're
+// in the order a key reaches them, so when two bindings are for the same keys
+// only the one which would run is included.
+func (this *UI) GetActiveBindings() []*KeyBinding {
+	levels := make([]bindingLevel, 0)
+	screen := this.getActiveScreen()
+	if screen != nil {
+		levels = screen.getBindingLevels()
+	}
+	levels = append(levels, this.getBindingLevel())
+
+	active := make([]*KeyBinding, 0)
+	seen := make(map[string]bool)
+	add := func(binding *KeyBinding) {
+		keys := binding.GetKeys().String()
+		if !seen[keys] {
+			seen[keys] = true
+			active = append(active, binding)
+		}
+	}
+
+	// Capture bindings run from the top down, then the others from the
+	// bottom up, and sequences go to the deepest level which has them
+	for i := len(levels) - 1; i >= 0; i-- {
+		for _, binding := range sortBindings(levels[i].bindings) {
+			if binding.Capture {
+				add(binding)
+			}
+		}
+	}
+	for _, level := range levels {
+		for _, binding := range sortBindings(level.bindings) {
+			if !binding.Capture {
+				add(binding)
+			}
+		}
+	}
+	for _, level := range levels {
+		for _, binding := range level.sequences {
+			add(binding)
+		}
+	}
+	return active
+}
+
+// Binds a key (on the ui level, so it only runs if nothing else consumed
+// it) to showing the help overlay.  The same key closes it again.
+func (this *UI) SetHelpKey(press KeyPress) *KeyBinding {
+	this.helpKey = &press
+	return this.AddKeyHandler(press, func(interface{}, Event) bool {
+		this.ShowHelp()
+		return true
+	}).Describe("Show this help", helpDefaultGroup)
+}
+
+// Shows a list of the bindings currently in effect (see GetActiveBindings)
+// in a modal layer on top of the active screen, grouped and with their
+// descriptions.  Esc, q or the help key close it.  Like the other screen
+// methods this has to run on the main loop - from a key handler, or Post.
+func (this *UI) ShowHelp() {
+	screen := this.getActiveScreen()
+	if screen == nil {
+		return
+	}
+
+	// Group the bindings, keeping the groups in the order they first show up
+	groups := make([]string, 0)
+	byGroup := make(map[string][]*KeyBinding)
+	keyWidth := 0
+	for _, binding := range this.GetActiveBindings() {
+		_, group := this.GetBindingDescription(binding)
+		if group == "" {
+			group = helpDefaultGroup
+		}
+		if byGroup[group] == nil {
+			groups = append(groups, group)
+		}
+		byGroup[group] = append(byGroup[group], binding)
+		if w := TextWidth(binding.GetKeys().String()); w > keyWidth {
+			keyWidth = w
+		}
+	}
+
+	// A header line for each group followed by its bindings
+	lines := make([]string, 0)
+	headers := make(map[int]bool)
+	for _, group := range groups {
+		headers[len(lines)] = true
+		lines = append(lines, group)
+		for _, binding := range byGroup[group] {
+			keys := binding.GetKeys().String()
+			description, _ := this.GetBindingDescription(binding)
+			if description == "" {
+				description = binding.Action
+			}
+			lines = append(lines, "  "+keys+strings.Repeat(" ", keyWidth-TextWidth(keys))+"  "+description)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No keys bound")
+	}
+
+	width := dialogMinWidth
+	for _, line := range lines {
+		if TextWidth(line)+4 > width {
+			width = TextWidth(line) + 4
+		}
+	}
+	height := len(lines) + 3
+
+	frame := func() (x1, x2, y1, y2 int) {
+		screenWidth, screenHeight := this.GetSize()
+		x1 = (screenWidth - width) / 2
+		y1 = (screenHeight - height) / 2
+		return x1, x1 + width - 1, y1, y1 + height - 1
+	}
+
+	layer := CreateLayer(true, true)
+	layer.AddWidget(NewLabel("Keys", WithRole(ROLE_DIALOG), WithBorder(true), WithFillBackground(true), WithLayout(frame)))
+
+	// The labels print one cell in from their rectangle, so each line's
+	// rectangle starts a row above it (and a column in from the border)
+	for i, line := range lines {
+		row := i + 2
+		role := ROLE_LABEL
+		if headers[i] {
+			role = ROLE_HEADING
+		}
+		layer.AddWidget(NewLabel(line, WithRole(role), WithLayout(func() (int, int, int, int) {
+			x1, x2, y1, _ := frame()
+			return x1 + 1, x2 - 1, y1 + row - 1, y1 + row
+		})))
+	}
+
+	closeHelp := func(interface{}, Event) bool {
+		layer.Dismiss()
+		return true
+	}
+	layer.AddCaptureKeyHandler(KeyPress{Key: termbox.KeyEsc}, closeHelp)
+	layer.AddCaptureKeyHandler(KeyPress{Ch: 'q'}, closeHelp)
+	if this.helpKey != nil {
+		layer.AddCaptureKeyHandler(*this.helpKey, closeHelp)
+	}
+
+	screen.PushLayer(layer)
+}
@@ -0,0 +1,43 @@
+package tbuikit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestHelpOverlayClosesWithEsc(t *testing.T) {
+	ui, mem, button := startUIWithButton(t)
+	ui.Invoke(func() {
+		ui.SetHelpKey(KeyPress{Ch: '?'})
+	})
+
+	mem.InjectChar('?')
+	waitForDialog(t, ui)
+
+	var text []string
+	ui.Invoke(func() {
+		ui.GetActiveScreen().Draw()
+		text = mem.GetRectText(CreateRectangle(0, 39, 0, 11))
+	})
+	found := false
+	for _, line := range text {
+		if strings.Contains(line, "Show this help") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the overlay to list the help key, got:\n%s", mem.String())
+	}
+
+	mem.InjectKey(termbox.KeyEsc)
+	waitFor(t, "the overlay to close", func() bool {
+		closed := false
+		ui.Invoke(func() {
+			closed = ui.GetActiveScreen().getModalLayer() == nil
+		})
+		return closed
+	})
+	assertFocusRestored(t, ui, button)
+}
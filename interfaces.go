@@ -5,16 +5,84 @@ package tbuikit
 // Interface defining widgets - a function to draw them to the screen
 // and one to call when the screen is resized.
 type Widget interface {
-	Draw()
+	// Draws the widget on a canvas bound to its rectangle, so 0,0 is the
+	// widget's top left corner and nothing it draws ends up outside of it.
+	Draw(canvas *Canvas)
 	CalculateSize()
 
+	// Gets the area the widget occupies on the screen, which is
+	// what mouse events get matched against.
+	GetRect() *Rectangle
+
 	// Handle widget selection
 	IsSelectable() bool
 	IsSelected() bool
 	Select()
 	Unselect()
 
-	// Handle keyboard events -- this is an interface{} because
-	// it can either be a termbox.Key or rune at the moment
-	HandleEvents(interface{})
+	// Handle events - keys while the widget is selected, and mouse
+	// events when the widget is under the pointer.  Returns whether or
+	// not the event was consumed, if it wasn't it bubbles up to the
+	// screen and the ui.
+	HandleEvents(event Event) bool
+}
+
+// Interface for widgets which hold other widgets, like PanelWidget.  The
+// screen looks inside of them when it's working out what's selectable and
+// what's under the mouse.
+type Container interface {
+	Widget
+
+	// Gets the widgets directly inside of the container
+	GetChildren() []Widget
+
+	// Gets the area the children are drawn in.  Their coordinates are
+	// relative to its top left corner.
+	GetInteriorRect() *Rectangle
+}
+
+// Interface for anything which can be drawn onto - widgets get handed one
+// of these in their Draw call instead of talking to the terminal directly.
+// Coordinates are in cells, with 0,0 being the top left corner.
+type Surface interface {
+	SetCell(x, y int, ch rune, fg, bg Color)
+	SetCursor(x, y int)
+	HideCursor()
+	Size() (width, height int)
+}
+
+// Interface for the terminal library which actually does the drawing and
+// produces the input events.  The ui talks to the terminal exclusively
+// through one of these, so a different library (or a fake terminal) can be
+// plugged in with SetBackend.  The default one wraps termbox-go.
+type Backend interface {
+	Surface
+
+	// Setting up and tearing down the terminal
+	Init() error
+	Close()
+
+	// Wipe the back buffer, and push the back buffer to the terminal
+	Clear(fg, bg Color) error
+	Flush() error
+
+	// Reads back what was drawn to the back buffer (used to dim what's
+	// underneath a layer).  Positions outside the buffer give an empty cell.
+	GetCell(x, y int) Cell
+
+	// Blocks until the next input (or resize) event is available, and
+	// Interrupt makes a blocked PollEvent return an EVENT_INTERRUPT.
+	// Interrupt waits for PollEvent to pick the interruption up.
+	PollEvent() Event
+	Interrupt()
+
+	// Turns reporting mouse events on or off.  Only called after Init.
+	SetMouseEnabled(enabled bool)
+}
+
+// The subset of *testing.T used by the test helpers, so that the library
+// itself doesn't have to import the testing package.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
 }
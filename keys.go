@@ -0,0 +1,216 @@
+package tbuikit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"unicode/utf8"
+)
+
+// Returned when a key spec can't be parsed
+var ErrInvalidKeySpec = errors.New("tbuikit: invalid key spec")
+
+// Names for the meta keys, used when printing key presses.  Some termbox keys
+// share their value with a ctrl combination (KeyTab is KeyCtrlI, for example),
+// in which case the more common name wins.
+var keyNames = map[termbox.Key]string{
+	termbox.KeyF1:         "f1",
+	termbox.KeyF2:         "f2",
+	termbox.KeyF3:         "f3",
+	termbox.KeyF4:         "f4",
+	termbox.KeyF5:         "f5",
+	termbox.KeyF6:         "f6",
+	termbox.KeyF7:         "f7",
+	termbox.KeyF8:         "f8",
+	termbox.KeyF9:         "f9",
+	termbox.KeyF10:        "f10",
+	termbox.KeyF11:        "f11",
+	termbox.KeyF12:        "f12",
+	termbox.KeyInsert:     "insert",
+	termbox.KeyDelete:     "delete",
+	termbox.KeyHome:       "home",
+	termbox.KeyEnd:        "end",
+	termbox.KeyPgup:       "pgup",
+	termbox.KeyPgdn:       "pgdn",
+	termbox.KeyArrowUp:    "up",
+	termbox.KeyArrowDown:  "down",
+	termbox.KeyArrowLeft:  "left",
+	termbox.KeyArrowRight: "right",
+	termbox.KeyTab:        "tab",
+	termbox.KeyEnter:      "enter",
+	termbox.KeyEsc:        "esc",
+	termbox.KeySpace:      "space",
+	termbox.KeyBackspace2: "backspace",
+	termbox.KeyCtrlSpace:  "ctrl+space",
+	termbox.KeyCtrlH:      "ctrl+h",
+	termbox.KeyCtrl4:      "ctrl+\\",
+	termbox.KeyCtrl5:      "ctrl+]",
+	termbox.KeyCtrl6:      "ctrl+6",
+	termbox.KeyCtrl7:      "ctrl+/",
+}
+
+// The other way around, for parsing key specs.  Built from keyNames, plus
+// a few alternative names.
+var keysByName = buildKeysByName()
+
+// Internal function for building the name to key map.
+func buildKeysByName() map[string]termbox.Key {
+	keys := map[string]termbox.Key{
+		"escape":    termbox.KeyEsc,
+		"return":    termbox.KeyEnter,
+		"pageup":    termbox.KeyPgup,
+		"pagedown":  termbox.KeyPgdn,
+		"del":       termbox.KeyDelete,
+		"ins":       termbox.KeyInsert,
+		"ctrl+@":    termbox.KeyCtrlSpace,
+		"ctrl+2":    termbox.KeyCtrlSpace,
+		"ctrl+[":    termbox.KeyEsc,
+		"ctrl+3":    termbox.KeyEsc,
+		"ctrl+8":    termbox.KeyBackspace2,
+		"ctrl+_":    termbox.KeyCtrl7,
+		"ctrl+~":    termbox.KeyCtrlSpace,
+		"backspace": termbox.KeyBackspace2,
+	}
+	for key, name := range keyNames {
+		keys[name] = key
+	}
+	return keys
+}
+
+// Parses a human readable key spec into a key press.  A spec is a key name
+// ("enter", "f5", "pgup"...) or a single printable character ("q", "Q", "?"),
+// optionally prefixed with modifiers joined by "+" - "ctrl+s", "alt+enter",
+// "ctrl+alt+x".  Names and modifiers aren't case sensitive, but printable
+// characters are.  These are the same names KeyPress.String produces.
+func ParseKeyPress(spec string) (KeyPress, error) {
+	var press KeyPress
+	ctrl := false
+
+	// Everything up to the last "+" is a modifier, unless the "+" is the
+	// key itself
+	name := spec
+	for {
+		i := strings.Index(name, "+")
+		if i <= 0 || i == len(name)-1 {
+			break
+		}
+		switch strings.ToLower(name[:i]) {
+		case "ctrl", "control":
+			ctrl = true
+		case "alt", "meta":
+			press.Mod |= MOD_ALT
+		default:
+			return press, fmt.Errorf("%w: %q", ErrInvalidKeySpec, spec)
+		}
+		name = name[i+1:]
+	}
+
+	lower := strings.ToLower(name)
+	key, known := keysByName[lower]
+	if ctrl {
+		key, known = keysByName["ctrl+"+lower]
+		if known {
+			press.Key = key
+		} else if len(lower) == 1 && lower[0] >= 'a' && lower[0] <= 'z' {
+			press.Key = termbox.KeyCtrlA + termbox.Key(lower[0]-'a')
+		} else {
+			return press, fmt.Errorf("%w: %q", ErrInvalidKeySpec, spec)
+		}
+	} else if known {
+		press.Key = key
+	} else if utf8.RuneCountInString(name) == 1 {
+		char, _ := utf8.DecodeRuneInString(name)
+		if char == ' ' {
+			press.Key = termbox.KeySpace
+		} else {
+			press.Ch = char
+		}
+	} else {
+		return press, fmt.Errorf("%w: %q", ErrInvalidKeySpec, spec)
+	}
+	return press, nil
+}
+
+// Same as ParseKeyPress, but panics if the spec is invalid.  Meant for specs
+// written directly in the code, like MustParseKeyPress("ctrl+s").
+func MustParseKeyPress(spec string) KeyPress {
+	press, err := ParseKeyPress(spec)
+	if err != nil {
+		panic(err)
+	}
+	return press
+}
+
+// Parses a key sequence, which is key specs (see ParseKeyPress) separated
+// by spaces - "ctrl+x ctrl+s" or "g g".  A single key spec is a sequence
+// of one key.
+func ParseKeySequence(spec string) (KeySequence, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidKeySpec, spec)
+	}
+
+	sequence := make(KeySequence, len(fields))
+	for i, field := range fields {
+		press, err := ParseKeyPress(field)
+		if err != nil {
+			return nil, err
+		}
+		sequence[i] = press
+	}
+	return sequence, nil
+}
+
+// A sequence of key presses which have to be typed one after the other,
+// like "ctrl+x ctrl+s" or "g g".
+type KeySequence []KeyPress
+
+// Gets a readable name for the key press, like "ctrl+s", "alt+enter" or "g".
+func (this KeyPress) String() string {
+	var name string
+	if this.Ch == ' ' {
+		name = "space"
+	} else if this.Ch != 0 {
+		name = string(this.Ch)
+	} else if known, ok := keyNames[this.Key]; ok {
+		name = known
+	} else if this.Key >= termbox.KeyCtrlA && this.Key <= termbox.KeyCtrlZ {
+		name = "ctrl+" + string(rune('a'+this.Key-termbox.KeyCtrlA))
+	} else {
+		name = "?"
+	}
+
+	if this.Mod&MOD_ALT != 0 {
+		name = "alt+" + name
+	}
+	return name
+}
+
+// Gets a readable version of the sequence, the key presses separated by spaces.
+func (this KeySequence) String() string {
+	names := make([]string, len(this))
+	for i, press := range this {
+		names[i] = press.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// Checks whether or not this sequence starts with another one (or is
+// the same).
+func (this KeySequence) HasPrefix(prefix KeySequence) bool {
+	if len(prefix) > len(this) {
+		return false
+	}
+	for i := range prefix {
+		if this[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks whether or not two sequences are made of the same key presses.
+func (this KeySequence) Equals(other KeySequence) bool {
+	return len(this) == len(other) && this.HasPrefix(other)
+}
@@ -0,0 +1,206 @@
+package tbuikit
+
+// Options for the New* widget constructors, like:
+//
+//	ok := NewButton("OK", WithLayout(rows.Add(Fixed(3))), OnPress(save))
+//
+// Every option is a setter which already exists on the widgets it applies
+// to, and they get applied in order.  An option which doesn't apply to the
+// widget it's given to (like OnPress on a label) does nothing, and logs a
+// warning in debug mode (see SetDebugLog).
+
+// Sets the CalcFunction working out where the widget goes.  Without one,
+// widgets cover the whole screen (see FullScreen).
+func WithLayout(calcFunction CalcFunction) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetCalcFunction(CalcFunction) })
+		if !ok {
+			optionMismatch("WithLayout", widget)
+			return
+		}
+		w.SetCalcFunction(calcFunction)
+	}
+}
+
+// Sets the style the widget gets drawn with in a state, over its theme's.
+func WithStyle(state WidgetState, style WidgetStyle) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface {
+			SetStyle(WidgetState, WidgetStyle)
+		})
+		if !ok {
+			optionMismatch("WithStyle", widget)
+			return
+		}
+		w.SetStyle(state, style)
+	}
+}
+
+// Sets the role the widget's theme styles it as, see SetRole.
+func WithRole(role WidgetRole) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetRole(WidgetRole) })
+		if !ok {
+			optionMismatch("WithRole", widget)
+			return
+		}
+		w.SetRole(role)
+	}
+}
+
+// Sets the theme the widget gets drawn with, over the screen's and the ui's.
+func WithTheme(theme *Theme) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetTheme(*Theme) })
+		if !ok {
+			optionMismatch("WithTheme", widget)
+			return
+		}
+		w.SetTheme(theme)
+	}
+}
+
+// Sets whether or not the widget can be selected.  Buttons and inputs can
+// be unless this says otherwise.
+func Selectable(selectable bool) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetSelectable(bool) })
+		if !ok {
+			optionMismatch("Selectable", widget)
+			return
+		}
+		w.SetSelectable(selectable)
+	}
+}
+
+// Selects the widget, so it starts out with the focus.
+func Selected() WidgetOption {
+	return func(widget Widget) {
+		if !widget.IsSelectable() {
+			optionMismatch("Selected", widget)
+			return
+		}
+		widget.Select()
+	}
+}
+
+// Disables the widget, see ButtonWidget.SetEnabled.
+func Disabled() WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetEnabled(bool) })
+		if !ok {
+			optionMismatch("Disabled", widget)
+			return
+		}
+		w.SetEnabled(false)
+	}
+}
+
+// Sets the callback run when a button gets pressed.
+func OnPress(callback ActionCallback) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetPressCallback(ActionCallback) })
+		if !ok {
+			optionMismatch("OnPress", widget)
+			return
+		}
+		w.SetPressCallback(callback)
+	}
+}
+
+// Sets the callback run whenever the widget gets selected.
+func OnFocus(callback WidgetCallback) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetFocusCallback(WidgetCallback) })
+		if !ok {
+			optionMismatch("OnFocus", widget)
+			return
+		}
+		w.SetFocusCallback(callback)
+	}
+}
+
+// Sets the callback run whenever the widget gets unselected.
+func OnBlur(callback WidgetCallback) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetBlurCallback(WidgetCallback) })
+		if !ok {
+			optionMismatch("OnBlur", widget)
+			return
+		}
+		w.SetBlurCallback(callback)
+	}
+}
+
+// Sets where the text of a button or label goes within it.
+func WithTextPosition(position ScreenPosition) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetTextPosition(ScreenPosition) })
+		if !ok {
+			optionMismatch("WithTextPosition", widget)
+			return
+		}
+		w.SetTextPosition(position)
+	}
+}
+
+// Sets whether or not a label or panel gets a border drawn around it.
+func WithBorder(draw bool) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetDrawBorders(bool) })
+		if !ok {
+			optionMismatch("WithBorder", widget)
+			return
+		}
+		w.SetDrawBorders(draw)
+	}
+}
+
+// Makes a label paint its whole rectangle, see LabelWidget.SetFillBackground.
+func WithFillBackground(fill bool) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetFillBackground(bool) })
+		if !ok {
+			optionMismatch("WithFillBackground", widget)
+			return
+		}
+		w.SetFillBackground(fill)
+	}
+}
+
+// Sets whether or not an input shows the cursor while it's selected.
+func WithCursor(hasCursor bool) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ SetHasCursor(bool) })
+		if !ok {
+			optionMismatch("WithCursor", widget)
+			return
+		}
+		w.SetHasCursor(hasCursor)
+	}
+}
+
+// Sets whether or not an input handles typing by itself, see
+// TextInputWidget.UseDefaultKeys.
+func WithDefaultKeys(use bool) WidgetOption {
+	return func(widget Widget) {
+		w, ok := widget.(interface{ UseDefaultKeys(bool) })
+		if !ok {
+			optionMismatch("WithDefaultKeys", widget)
+			return
+		}
+		w.UseDefaultKeys(use)
+	}
+}
+
+// Applies options to a widget, in order.
+func applyOptions(widget Widget, options []WidgetOption) {
+	for _, option := range options {
+		option(widget)
+	}
+}
+
+// Warns about an option given to a widget it doesn't apply to.
+func optionMismatch(option string, widget Widget) {
+	debugf("%s doesn't apply to a %T, ignoring it", option, widget)
+}
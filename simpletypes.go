@@ -14,7 +14,35 @@ type CalcFunction func() (x1, x2, y1, y2 int)
 // text is inside a widget.
 type ScreenPosition int
 
-// Holds a string and a color to print it in
+// Represents what was done with the mouse - a button being pressed
+// or released, or the wheel being scrolled.
+type MouseAction int
+
+// A mouse event, along with the position (in cells) of the pointer
+// when it happened.
+type MouseEvent struct {
+	Action MouseAction
+	X      int
+	Y      int
+}
+
+// A callback run when something happens to a screen - it being shown,
+// hidden or drawn.  It gets passed the screen in question.
+type ScreenCallback func(*Screen)
+
+// A callback run when a screen gets resized, which gets passed the
+// screen along with its new dimensions.
+type ScreenResizeCallback func(screen *Screen, width, height int)
+
+// A direction to move the selection in, see Screen.SelectWidgetInDirection
+type Direction int
+
+// A callback run when something happens to a widget, like it gaining or
+// losing the focus.  It gets passed the widget in question.
+type WidgetCallback func(Widget)
+
+// Holds a string and a color to print it in (a termbox one, which is how
+// it always was)
 type ColorizedString struct {
 	Color termbox.Attribute
 	Text  string
@@ -27,4 +55,53 @@ type ColorizedString struct {
 //
 // The key pressed can either be a rune (for printable keys) or
 // a meta character of type termbox.Key
+//
+// This is the older, untyped version of EventHandler - see AdaptEventCallback.
 type EventCallback func(interface{}, interface{})
+
+// A handler function which can be mapped to a key in various levels of
+// the interface.  It's passed a pointer to the ui element which was called
+// and the event which triggered it, and returns whether or not it consumed
+// the event - returning false lets the event keep propagating (see KeyBinding).
+type EventHandler func(source interface{}, event Event) bool
+
+// A callback run when a widget does something, like a button being pressed.
+// It's passed the widget and the event which caused it.
+type ActionCallback func(source interface{}, event Event)
+
+// A callback run when the keys typed so far of a key sequence change,
+// meant for showing them in a status line.  pending is empty once the
+// sequence is done or abandoned.
+type KeySequenceCallback func(ui *UI, pending KeySequence)
+
+// What kind of event an Event is
+type EventType int
+
+// Modifier keys held down during an event, as a bitmask
+type Modifier int
+
+// What a widget is, as far as themes are concerned - see Theme
+type WidgetRole int
+
+// What state a widget is drawn in, as far as themes are concerned
+type WidgetState int
+
+// Sets something up on a widget as it's being made, see NewButton and the
+// With* functions.
+type WidgetOption func(widget Widget)
+
+// A color a cell gets drawn in, one of the COLOR_* constants.  Foreground
+// colors can carry attributes as well, combined as COLOR_RED | Color(ATTR_BOLD)
+// (Style takes care of that).
+type Color uint64
+
+// Text attributes, the ATTR_* constants, which combine with |
+type Attr uint64
+
+// What's drawn in a single cell of a Surface - a character and its colors,
+// the foreground one including any attributes.
+type Cell struct {
+	Ch rune
+	Fg Color
+	Bg Color
+}
@@ -0,0 +1,186 @@
+package tbuikit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Helpers for snapshot ("golden file") testing of rendered screens
+
+// Characters used to encode colors in snapshots - a color is written as its
+// termbox number in base 36, with ColorDefault (0) being "." to keep empty
+// areas readable.  Anything which doesn't fit in a single digit (256 color and
+// RGB modes) is written as "?".
+const snapshotColorDigits = ".123456789abcdefghijklmnopqrstuvwxyz"
+
+// Mask to get only the color out of an attribute, dropping bold and friends
+const snapshotColorMask = Color(ATTR_BOLD) - 1
+
+// Draws a screen onto a fresh memory backend of the given size, without
+// needing a ui or a terminal.  The screen gets resized to the backend first,
+// so that CalcFunctions relying on GetTermboxWidth / GetTermboxHeight see the
+// offscreen dimensions - which means the default backend gets swapped out
+// while this runs, so it shouldn't be called from parallel tests.  The
+// current backend is put back afterwards.
+func RenderScreen(screen *Screen, width, height int) *MemoryBackend {
+	mem := CreateMemoryBackend(width, height)
+
+	previous := GetBackend()
+	SetBackend(mem)
+	defer SetBackend(previous)
+
+	previousScreenBackend := screen.backend
+	screen.backend = mem
+	defer func() { screen.backend = previousScreenBackend }()
+
+	screen.DoResize()
+	screen.Draw()
+	return mem
+}
+
+// Serializes the grid into a stable text format meant to be checked into
+// golden files.  It has four layers, each one row per line and each row
+// wrapped in "|" so trailing spaces survive editors: the text itself, the
+// foreground and background colors (see snapshotColorDigits) and the
+// attributes, written as a hex digit of bold = 1, underline = 2, reverse = 4
+// and dim = 8 ("." for none).  Like GetRectText, the text leaves out the cell
+// covered by the second half of a double width rune, so every layer takes up
+// the same number of columns.
+func (this *MemoryBackend) Snapshot() string {
+	width, height := this.Size()
+
+	var text, fg, bg, attrs bytes.Buffer
+	for y := 0; y < height; y++ {
+		text.WriteByte('|')
+		fg.WriteByte('|')
+		bg.WriteByte('|')
+		attrs.WriteByte('|')
+		covered := false
+		for x := 0; x < width; x++ {
+			cell := this.GetCell(x, y)
+			ch := cell.Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			if !covered {
+				text.WriteRune(ch)
+			}
+			covered = !covered && runewidth.RuneWidth(ch) == 2
+			fg.WriteByte(snapshotColor(cell.Fg))
+			bg.WriteByte(snapshotColor(cell.Bg))
+			attrs.WriteByte(snapshotAttributes(cell.Fg | cell.Bg))
+		}
+		text.WriteString("|\n")
+		fg.WriteString("|\n")
+		bg.WriteString("|\n")
+		attrs.WriteString("|\n")
+	}
+
+	var snapshot bytes.Buffer
+	fmt.Fprintf(&snapshot, "size %dx%d\n", width, height)
+	fmt.Fprintf(&snapshot, "-- text --\n%s", text.String())
+	fmt.Fprintf(&snapshot, "-- fg --\n%s", fg.String())
+	fmt.Fprintf(&snapshot, "-- bg --\n%s", bg.String())
+	fmt.Fprintf(&snapshot, "-- attr --\n%s", attrs.String())
+	return snapshot.String()
+}
+
+// Compares the grid's snapshot against a golden file, see AssertGolden.
+func (this *MemoryBackend) AssertSnapshot(t TestingT, goldenPath string, update bool) {
+	t.Helper()
+	AssertGolden(t, goldenPath, this.Snapshot(), update)
+}
+
+// Fails the test if actual doesn't match the contents of the golden file.
+// When update is true the golden file is (re)written with actual instead,
+// which is how golden files get created in the first place.  Tests typically
+// wire this to a flag of their own:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//	...
+//	mem.AssertSnapshot(t, "testdata/login.golden", *update)
+func AssertGolden(t TestingT, goldenPath string, actual string, update bool) {
+	t.Helper()
+
+	if update {
+		err := os.MkdirAll(filepath.Dir(goldenPath), 0755)
+		if err == nil {
+			err = os.WriteFile(goldenPath, []byte(actual), 0644)
+		}
+		if err != nil {
+			t.Errorf("couldn't update golden file %s: %v", goldenPath, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Errorf("couldn't read golden file %s (run with update to create it): %v", goldenPath, err)
+		return
+	}
+
+	if string(expected) != actual {
+		t.Errorf("output doesn't match golden file %s:\n%s", goldenPath, describeGoldenDiff(string(expected), actual))
+	}
+}
+
+// Builds a short, line based description of how two snapshots differ.
+func describeGoldenDiff(expected, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	count := len(expectedLines)
+	if len(actualLines) > count {
+		count = len(actualLines)
+	}
+
+	var diff bytes.Buffer
+	for i := 0; i < count; i++ {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a {
+			fmt.Fprintf(&diff, "line %d:\n  want: %s\n  got:  %s\n", i+1, e, a)
+		}
+	}
+	return diff.String()
+}
+
+// Encodes the color part of an attribute as a single character.
+func snapshotColor(attr Color) byte {
+	color := int(attr & snapshotColorMask)
+	if color >= len(snapshotColorDigits) {
+		return '?'
+	}
+	return snapshotColorDigits[color]
+}
+
+// Encodes the attributes we care about as a single hex digit.
+func snapshotAttributes(attr Color) byte {
+	flags := 0
+	if attr&Color(ATTR_BOLD) != 0 {
+		flags |= 1
+	}
+	if attr&Color(ATTR_UNDERLINE) != 0 {
+		flags |= 2
+	}
+	if attr&Color(ATTR_REVERSE) != 0 {
+		flags |= 4
+	}
+	if attr&Color(ATTR_DIM) != 0 {
+		flags |= 8
+	}
+	if flags == 0 {
+		return '.'
+	}
+	return "0123456789abcdef"[flags]
+}
@@ -0,0 +1,125 @@
+package tbuikit
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+// Run the tests with -update to rewrite the golden files in testdata
+var update = flag.Bool("update", false, "update golden files")
+
+// A CalcFunction for a fixed rectangle
+func at(x1, x2, y1, y2 int) CalcFunction {
+	return func() (int, int, int, int) {
+		return x1, x2, y1, y2
+	}
+}
+
+// Renders a single widget on a screen of its own and checks it against its
+// golden file.
+func assertWidgetGolden(t *testing.T, name string, widget Widget, width, height int) {
+	t.Helper()
+
+	screen := new(Screen)
+	screen.AddWidget(widget)
+	mem := RenderScreen(screen, width, height)
+	mem.AssertSnapshot(t, filepath.Join("testdata", name+".golden"), *update)
+}
+
+func TestButtonGolden(t *testing.T) {
+	assertWidgetGolden(t, "button", NewButton("OK", WithLayout(at(0, 9, 0, 2)), Selected()), 12, 4)
+}
+
+func TestLabelGolden(t *testing.T) {
+	assertWidgetGolden(t, "label", NewLabel("Name:", WithBorder(true), WithLayout(at(0, 9, 0, 2))), 12, 4)
+}
+
+func TestTextInputGolden(t *testing.T) {
+	buffer := new(TextInputBuffer)
+	buffer.SetText("typed")
+	assertWidgetGolden(t, "textinput", NewTextInput(buffer, WithLayout(at(0, 11, 0, 2)), Selected()), 14, 4)
+}
+
+func TestPasswordInputGolden(t *testing.T) {
+	buffer := new(TextInputBuffer)
+	buffer.SetText("hello world 日本")
+	assertWidgetGolden(t, "password", NewPasswordInput(buffer, WithLayout(at(0, 11, 0, 3))), 14, 5)
+}
+
+func TestStringDisplayGolden(t *testing.T) {
+	buffer := new(StringBuffer)
+	buffer.Prepare(10)
+	buffer.Add("first line")
+	buffer.Add("second")
+	assertWidgetGolden(t, "stringdisplay", NewStringDisplay(buffer, WithLayout(at(0, 13, 0, 3))), 16, 5)
+}
+
+func TestColorizedTextGolden(t *testing.T) {
+	buffer := new(ColorizedStringBuffer)
+	buffer.Prepare(10)
+	buffer.Add(&ColorizedString{Color: termbox.ColorRed, Text: "error"})
+	buffer.Add(&ColorizedString{Color: termbox.ColorGreen, Text: "ok"})
+	assertWidgetGolden(t, "colorizedtext", NewColorizedText(buffer, WithLayout(at(0, 13, 0, 3))), 16, 5)
+}
+
+func TestPanelGolden(t *testing.T) {
+	panel := NewPanel("Options", WithLayout(at(0, 15, 0, 4)))
+	panel.AddWidget(NewButton("Go", WithLayout(at(1, 6, 0, 2))))
+	assertWidgetGolden(t, "panel", panel, 18, 6)
+}
+
+func TestFooterGolden(t *testing.T) {
+	ui := new(UI)
+	ui.AddCharKeyCallback('q', func(interface{}, interface{}) {}).Describe("Quit", "")
+	ui.AddKeyHandler(MustParseKeyPress("ctrl+s"), func(interface{}, Event) bool { return true }).Describe("Save", "")
+	assertWidgetGolden(t, "footer", NewFooter(ui, WithLayout(at(0, 23, 0, 0))), 24, 1)
+}
+
+func TestAssertGoldenUpdatesThenCompares(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "out.golden")
+
+	// Missing golden files fail until they're written with update
+	rt := new(recordingT)
+	AssertGolden(rt, path, "first", false)
+	if len(rt.errors) != 1 {
+		t.Errorf("expected a missing golden file to fail, got %q", rt.errors)
+	}
+
+	rt = new(recordingT)
+	AssertGolden(rt, path, "first", true)
+	if len(rt.errors) != 0 {
+		t.Errorf("expected the update to work, got %q", rt.errors)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil || string(written) != "first" {
+		t.Errorf("expected the golden file to hold %q, got %q (%v)", "first", written, err)
+	}
+
+	AssertGolden(t, path, "first", false)
+
+	rt = new(recordingT)
+	AssertGolden(rt, path, "second", false)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "want: first") {
+		t.Errorf("expected a mismatch describing the difference, got %q", rt.errors)
+	}
+}
+
+func TestSnapshotLayersLineUpWithWideRunes(t *testing.T) {
+	mem := CreateMemoryBackend(6, 1)
+	SurfacePrint(mem, 0, 0, COLOR_RED, COLOR_DEFAULT, "a日本")
+
+	lines := strings.Split(mem.Snapshot(), "\n")
+	text, fg := lines[2], lines[4]
+	if text != "|a日本 |" {
+		t.Errorf("expected the covered cells left out, got %q", text)
+	}
+	if runewidth.StringWidth(text) != len(fg) {
+		t.Errorf("expected the text and fg layers to be as wide, got %q and %q", text, fg)
+	}
+}
@@ -0,0 +1,79 @@
+package tbuikit
+
+// The themes which come with the library.  Each call makes a new theme, so
+// changing one doesn't change it for anyone else.
+
+// What gets used when neither the ui nor the screen has a theme
+var defaultTheme = CreateDefaultTheme()
+
+// Makes the default theme - white on the terminal's background, with the
+// focused widget's border in bold yellow and disabled widgets dimmed.
+func CreateDefaultTheme() *Theme {
+	normal := CreateStyle(COLOR_WHITE, COLOR_DEFAULT)
+	focused := CreateStyle(COLOR_YELLOW, COLOR_DEFAULT).Bold()
+	disabled := CreateStyle(COLOR_BLACK|Color(ATTR_BOLD), COLOR_DEFAULT)
+	accent := CreateStyle(COLOR_CYAN, COLOR_DEFAULT).Bold()
+
+	return CreateTheme("default").
+		SetStyle(ROLE_DEFAULT, STATE_NORMAL, WidgetStyle{Text: normal, Border: normal, Accent: accent}).
+		SetStyle(ROLE_DEFAULT, STATE_FOCUSED, WidgetStyle{Text: normal, Border: focused, Accent: accent}).
+		SetStyle(ROLE_DEFAULT, STATE_DISABLED, WidgetStyle{Text: disabled, Border: disabled, Accent: disabled}).
+		SetStyle(ROLE_BUTTON, STATE_FOCUSED, WidgetStyle{Text: focused, Border: focused, Accent: accent}).
+		SetStyle(ROLE_PANEL, STATE_NORMAL, WidgetStyle{Text: normal, Border: normal, Accent: normal.Bold()}).
+		SetStyle(ROLE_FOOTER, STATE_NORMAL, WidgetStyle{Text: normal, Border: normal, Accent: focused}).
+		SetStyle(ROLE_DIALOG, STATE_NORMAL, WidgetStyle{Text: normal.Bold(), Border: normal, Accent: accent}).
+		SetStyle(ROLE_HEADING, STATE_NORMAL, WidgetStyle{Text: focused, Border: normal, Accent: focused})
+}
+
+// Makes a theme with white text on blue, the way old DOS programs looked.
+func CreateBlueTheme() *Theme {
+	normal := CreateStyle(COLOR_WHITE, COLOR_BLUE)
+	border := CreateStyle(COLOR_CYAN, COLOR_BLUE)
+	focused := CreateStyle(COLOR_YELLOW, COLOR_BLUE).Bold()
+	disabled := CreateStyle(COLOR_BLACK|Color(ATTR_BOLD), COLOR_BLUE)
+	selected := CreateStyle(COLOR_BLACK, COLOR_CYAN)
+
+	return CreateTheme("blue").
+		SetStyle(ROLE_DEFAULT, STATE_NORMAL, WidgetStyle{Text: normal, Border: border, Accent: focused}).
+		SetStyle(ROLE_DEFAULT, STATE_FOCUSED, WidgetStyle{Text: normal, Border: focused, Accent: focused}).
+		SetStyle(ROLE_DEFAULT, STATE_DISABLED, WidgetStyle{Text: disabled, Border: disabled, Accent: disabled}).
+		SetStyle(ROLE_BUTTON, STATE_FOCUSED, WidgetStyle{Text: selected, Border: focused, Accent: focused}).
+		SetStyle(ROLE_INPUT, STATE_NORMAL, WidgetStyle{Text: selected, Border: border, Accent: focused}).
+		SetStyle(ROLE_INPUT, STATE_FOCUSED, WidgetStyle{Text: selected, Border: focused, Accent: focused}).
+		SetStyle(ROLE_FOOTER, STATE_NORMAL, WidgetStyle{Text: selected, Border: selected, Accent: CreateStyle(COLOR_RED, COLOR_CYAN)}).
+		SetStyle(ROLE_DIALOG, STATE_NORMAL, WidgetStyle{Text: normal.Bold(), Border: border, Accent: focused}).
+		SetStyle(ROLE_HEADING, STATE_NORMAL, WidgetStyle{Text: focused, Border: border, Accent: focused})
+}
+
+// Makes a theme without any colors, for terminals which don't have them (or
+// people who don't want them).  Focus is shown with bold and reversed text.
+func CreateMonochromeTheme() *Theme {
+	normal := CreateStyle(COLOR_DEFAULT, COLOR_DEFAULT)
+
+	return CreateTheme("monochrome").
+		SetStyle(ROLE_DEFAULT, STATE_NORMAL, WidgetStyle{Text: normal, Border: normal, Accent: normal.Bold()}).
+		SetStyle(ROLE_DEFAULT, STATE_FOCUSED, WidgetStyle{Text: normal, Border: normal.Bold(), Accent: normal.Bold()}).
+		SetStyle(ROLE_DEFAULT, STATE_DISABLED, WidgetStyle{Text: normal, Border: normal, Accent: normal}).
+		SetStyle(ROLE_BUTTON, STATE_FOCUSED, WidgetStyle{Text: normal.Reverse(), Border: normal.Bold(), Accent: normal.Bold()}).
+		SetStyle(ROLE_FOOTER, STATE_NORMAL, WidgetStyle{Text: normal.Reverse(), Border: normal.Reverse(), Accent: normal.Reverse().Bold()}).
+		SetStyle(ROLE_DIALOG, STATE_NORMAL, WidgetStyle{Text: normal.Bold(), Border: normal, Accent: normal.Bold()}).
+		SetStyle(ROLE_HEADING, STATE_NORMAL, WidgetStyle{Text: normal.Bold().Underline(), Border: normal, Accent: normal.Bold()})
+}
+
+// Makes a theme with bright, bold colors on black, for when the others are
+// hard to read.
+func CreateHighContrastTheme() *Theme {
+	normal := CreateStyle(COLOR_WHITE|Color(ATTR_BOLD), COLOR_BLACK)
+	focused := CreateStyle(COLOR_BLACK, COLOR_YELLOW).Bold()
+	border := CreateStyle(COLOR_YELLOW, COLOR_BLACK).Bold()
+	disabled := CreateStyle(COLOR_WHITE, COLOR_BLACK).Underline()
+
+	return CreateTheme("high contrast").
+		SetStyle(ROLE_DEFAULT, STATE_NORMAL, WidgetStyle{Text: normal, Border: normal, Accent: border}).
+		SetStyle(ROLE_DEFAULT, STATE_FOCUSED, WidgetStyle{Text: normal, Border: border, Accent: border}).
+		SetStyle(ROLE_DEFAULT, STATE_DISABLED, WidgetStyle{Text: disabled, Border: disabled, Accent: disabled}).
+		SetStyle(ROLE_BUTTON, STATE_FOCUSED, WidgetStyle{Text: focused, Border: border, Accent: border}).
+		SetStyle(ROLE_INPUT, STATE_FOCUSED, WidgetStyle{Text: normal, Border: border, Accent: border}).
+		SetStyle(ROLE_DIALOG, STATE_NORMAL, WidgetStyle{Text: normal, Border: border, Accent: border}).
+		SetStyle(ROLE_HEADING, STATE_NORMAL, WidgetStyle{Text: border, Border: border, Accent: border})
+}
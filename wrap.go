@@ -0,0 +1,127 @@
+package tbuikit
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Text wrapping, used by the buffers to fit their text to a widget's width
+
+// Wraps text into lines no wider than width cells, breaking between words.
+// Words too long to fit on a line of their own get broken up wherever the
+// line runs out, and explicit newlines always start a new line (so empty
+// lines are kept).  The spaces where a line gets broken are dropped.
+//
+// Every line after the first one is indented by hangingIndent spaces, which
+// counts towards its width - handy for lining up the rest of a chat message
+// past the sender's name.  A width under 1 only splits on the newlines.
+func WrapText(text string, width, hangingIndent int) []string {
+	paragraphs := strings.Split(text, "\n")
+	if width < 1 {
+		return paragraphs
+	}
+	if hangingIndent >= width || hangingIndent < 0 {
+		hangingIndent = 0
+	}
+	indent := strings.Repeat(" ", hangingIndent)
+
+	lines := make([]string, 0)
+	line := ""
+	lineWidth := 0
+	room := width
+
+	// Finishes the line being built and starts on the next one, which
+	// gets the indent.  Trailing spaces are only dropped where the text
+	// gets wrapped, so a text input still shows the space just typed.
+	breakLine := func(wrapped bool) {
+		if wrapped {
+			line = strings.TrimRightFunc(line, unicode.IsSpace)
+		}
+		if len(lines) > 0 {
+			line = indent + line
+		}
+		lines = append(lines, line)
+		line = ""
+		lineWidth = 0
+		room = width - hangingIndent
+	}
+
+	for _, paragraph := range paragraphs {
+		for _, word := range splitWords(paragraph) {
+			wordWidth := runewidth.StringWidth(word)
+			if lineWidth+wordWidth <= room {
+				line += word
+				lineWidth += wordWidth
+				continue
+			}
+
+			// Spaces which don't fit are where the line breaks
+			if isSpaceWord(word) {
+				if lineWidth > 0 {
+					breakLine(true)
+				}
+				continue
+			}
+
+			if lineWidth > 0 {
+				breakLine(true)
+			}
+			for wordWidth > room {
+				head, tail := splitAtWidth(word, room)
+				line = head
+				breakLine(true)
+				word = tail
+				wordWidth = runewidth.StringWidth(word)
+			}
+			line = word
+			lineWidth = wordWidth
+		}
+		breakLine(false)
+	}
+	return lines
+}
+
+// Splits a line of text into words and the runs of spaces between them,
+// which put back together give the text back.
+func splitWords(text string) []string {
+	words := make([]string, 0)
+	start := 0
+	inSpace := false
+	for i, c := range text {
+		space := unicode.IsSpace(c)
+		if i > start && space != inSpace {
+			words = append(words, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// Checks if a word from splitWords is a run of spaces
+func isSpaceWord(word string) bool {
+	for _, c := range word {
+		return unicode.IsSpace(c)
+	}
+	return false
+}
+
+// Splits a word into the part which fits in width cells and the rest.  At
+// least one character always goes in the first part, so a character wider
+// than the room there is can't get stuck.
+func splitAtWidth(word string, width int) (string, string) {
+	used := 0
+	for i, c := range word {
+		runeWidth := runewidth.RuneWidth(c)
+		if used+runeWidth > width && i > 0 {
+			return word[:i], word[i:]
+		}
+		used += runeWidth
+	}
+	return word, ""
+}